@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PreviewTokenPrefix marks preview tokens, like xvc_ marks API tokens
+const PreviewTokenPrefix = "xvp_"
+
+// PreviewClaims scope a preview token to a content type and optionally one entry
+type PreviewClaims struct {
+	ContentType string `json:"ct"`
+	EntryID     uint   `json:"eid,omitempty"` // 0 for every entry of the content type
+	jwt.RegisteredClaims
+}
+
+// previewKey signs preview tokens. It is derived from the JWT secret so that a preview
+// token never validates as a login token and the other way round.
+func previewKey() []byte {
+	sum := sha256.Sum256(append([]byte("preview:"), jwtSecret...))
+	return sum[:]
+}
+
+// GeneratePreviewToken signs a preview token for the stored token record id
+func GeneratePreviewToken(id uint, contentTypeUID string, entryID uint, expiresAt time.Time) (string, error) {
+	claims := PreviewClaims{
+		ContentType: contentTypeUID,
+		EntryID:     entryID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        strconv.FormatUint(uint64(id), 10),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(previewKey())
+	if err != nil {
+		return "", err
+	}
+	return PreviewTokenPrefix + signed, nil
+}
+
+// ValidatePreviewToken checks the signature and expiry of a preview token. Whether it
+// was revoked is up to the caller, using the record ID in claims.ID.
+func ValidatePreviewToken(tokenString string) (*PreviewClaims, error) {
+	if !strings.HasPrefix(tokenString, PreviewTokenPrefix) {
+		return nil, errors.New("not a preview token")
+	}
+
+	token, err := jwt.ParseWithClaims(strings.TrimPrefix(tokenString, PreviewTokenPrefix), &PreviewClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return previewKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*PreviewClaims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, errors.New("invalid token")
+}
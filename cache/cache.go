@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Entry is a cached HTTP response
+type Entry struct {
+	Status    int
+	Header    http.Header
+	Body      []byte
+	Tags      []string
+	ExpiresAt time.Time
+}
+
+// Backend stores cached responses. Implementations must be safe for concurrent use.
+type Backend interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	// InvalidateTags removes all entries carrying any of the tags and returns how many were removed
+	InvalidateTags(tags ...string) int
+	Purge()
+	Len() int
+}
+
+// Cache wraps a backend with a TTL and hit/miss counters
+type Cache struct {
+	backend Backend
+	ttl     time.Duration
+
+	// Every invalidation gets the next generation. A response is only stored if none of
+	// its tags was invalidated after the generation it was built at, see Generation.
+	mu          sync.Mutex
+	generation  uint64
+	invalidated map[string]uint64 // tag -> generation of its last invalidation
+	floor       uint64            // Responses built before this generation are not stored
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	sets          atomic.Int64
+	invalidations atomic.Int64
+}
+
+// Stats is a snapshot of cache counters for monitoring
+type Stats struct {
+	Enabled       bool    `json:"enabled"`
+	Entries       int     `json:"entries"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRate       float64 `json:"hitRate"`
+	Sets          int64   `json:"sets"`
+	Invalidations int64   `json:"invalidations"`
+}
+
+// maxInvalidatedTags bounds the invalidation generations a cache remembers per tag
+const maxInvalidatedTags = 10000
+
+// Default is the response cache used by the public API, nil when caching is disabled
+var Default *Cache
+
+// Init enables the response cache with the given backend
+func Init(backend Backend, ttl time.Duration) {
+	Default = New(backend, ttl)
+}
+
+func New(backend Backend, ttl time.Duration) *Cache {
+	return &Cache{backend: backend, ttl: ttl, invalidated: make(map[string]uint64)}
+}
+
+func (c *Cache) Get(key string) (*Entry, bool) {
+	entry, ok := c.backend.Get(key)
+	if ok && !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		ok = false
+	}
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return entry, ok
+}
+
+func (c *Cache) Set(key string, entry *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, entry)
+}
+
+func (c *Cache) set(key string, entry *Entry) {
+	if c.ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(c.ttl)
+	}
+	c.backend.Set(key, entry)
+	c.sets.Add(1)
+}
+
+// Generation returns the current invalidation generation. Take it before loading the data
+// of a response and pass it to SetFresh.
+func (c *Cache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// SetFresh stores a response unless the cache was purged or one of its tags was
+// invalidated after generation: the response may then contain data that a concurrent
+// write already replaced. It reports whether the response was stored.
+func (c *Cache) SetFresh(key string, entry *Entry, generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.floor > generation {
+		return false
+	}
+	for _, tag := range entry.Tags {
+		if c.invalidated[tag] > generation {
+			return false
+		}
+	}
+	c.set(key, entry)
+	return true
+}
+
+func (c *Cache) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if len(c.invalidated) >= maxInvalidatedTags {
+		// Forgetting the tags only keeps the responses in flight from being stored
+		c.floor = c.generation
+		c.invalidated = make(map[string]uint64)
+	}
+	for _, tag := range tags {
+		c.invalidated[tag] = c.generation
+	}
+	removed := c.backend.InvalidateTags(tags...)
+	c.invalidations.Add(int64(removed))
+}
+
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Generations of single tags are superseded by the purge
+	c.generation++
+	c.floor = c.generation
+	c.invalidated = make(map[string]uint64)
+	c.invalidations.Add(int64(c.backend.Len()))
+	c.backend.Purge()
+}
+
+func (c *Cache) Stats() Stats {
+	hits, misses := c.hits.Load(), c.misses.Load()
+	stats := Stats{
+		Enabled:       true,
+		Entries:       c.backend.Len(),
+		Hits:          hits,
+		Misses:        misses,
+		Sets:          c.sets.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+	if hits+misses > 0 {
+		stats.HitRate = float64(hits) / float64(hits+misses)
+	}
+	return stats
+}
+
+// Invalidate removes cached responses with any of the tags from the default cache
+func Invalidate(tags ...string) {
+	if Default != nil {
+		Default.Invalidate(tags...)
+	}
+}
+
+// Tags
+
+// ContentTypeTag marks every response that depends on a content type
+func ContentTypeTag(uid string) string {
+	return "ct:" + uid
+}
+
+// ListTag marks list responses of a content type, which change on any entry write
+func ListTag(uid string) string {
+	return "list:" + uid
+}
+
+// EntryTag marks responses that include a specific entry
+func EntryTag(uid string, id uint) string {
+	return "entry:" + uid + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
+// ETagMatches implements the weak comparison of an If-None-Match header
+func ETagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+const contextTagsKey = "cacheTags"
+
+// AddTags attaches invalidation tags to the current response. Only responses
+// with tags are stored by the response cache middleware.
+func AddTags(c *gin.Context, tags ...string) {
+	if Default == nil {
+		return
+	}
+	existing := c.GetStringSlice(contextTagsKey)
+	c.Set(contextTagsKey, append(existing, tags...))
+}
+
+// TagsFromContext returns the tags attached with AddTags
+func TagsFromContext(c *gin.Context) []string {
+	return c.GetStringSlice(contextTagsKey)
+}
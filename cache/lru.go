@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRU is an in-memory Backend that evicts the least recently used entries
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	tags     map[string]map[string]struct{} // tag -> keys
+}
+
+type lruItem struct {
+	key   string
+	entry *Entry
+}
+
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &LRU{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+func (l *LRU) Get(key string) (*Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruItem).entry, true
+}
+
+func (l *LRU) Set(key string, entry *Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.items[key]; ok {
+		l.remove(element)
+	}
+
+	l.items[key] = l.order.PushFront(&lruItem{key: key, entry: entry})
+	for _, tag := range entry.Tags {
+		if l.tags[tag] == nil {
+			l.tags[tag] = make(map[string]struct{})
+		}
+		l.tags[tag][key] = struct{}{}
+	}
+
+	for l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *LRU) InvalidateTags(tags ...string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for _, tag := range tags {
+		for key := range l.tags[tag] {
+			if element, ok := l.items[key]; ok {
+				l.remove(element)
+				removed++
+			}
+		}
+		delete(l.tags, tag)
+	}
+	return removed
+}
+
+func (l *LRU) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.items = make(map[string]*list.Element)
+	l.order.Init()
+	l.tags = make(map[string]map[string]struct{})
+}
+
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove drops an element and its tag references. Caller must hold the lock.
+func (l *LRU) remove(element *list.Element) {
+	item := element.Value.(*lruItem)
+	l.order.Remove(element)
+	delete(l.items, item.key)
+	for _, tag := range item.entry.Tags {
+		if keys := l.tags[tag]; keys != nil {
+			delete(keys, item.key)
+			if len(keys) == 0 {
+				delete(l.tags, tag)
+			}
+		}
+	}
+}
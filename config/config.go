@@ -21,7 +21,24 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiration  string
 	CORSOrigin     string
+	SiteURL        string
 	AllowedOrigins []string
+	TrustedProxies []string
+	CacheEnabled   bool
+	CacheSize      int
+	CacheTTL       string
+
+	WebhookMaxAttempts int
+	WebhookRetryBase   string
+	WebhookTimeout     string
+
+	CaptchaProvider string
+	CaptchaSecret   string
+
+	MailSinkDir string
+	MailFrom    string
+
+	Plugins []string
 }
 
 var AppConfig *Config
@@ -43,7 +60,24 @@ func LoadConfig() {
 		JWTSecret:      getEnv("JWT_SECRET", "change-this-secret-key-in-production"),
 		JWTExpiration:  getEnv("JWT_EXPIRATION", "24h"),
 		CORSOrigin:     getEnv("CORS_ORIGIN", "http://localhost:5173"),
+		SiteURL:        getEnv("SITE_URL", ""),
 		AllowedOrigins: getEnvArray("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
+		TrustedProxies: getEnvArray("TRUSTED_PROXIES", []string{}),
+		CacheEnabled:   getEnvBool("CACHE_ENABLED", false),
+		CacheSize:      getEnvInt("CACHE_SIZE", 1000),
+		CacheTTL:       getEnv("CACHE_TTL", "5m"),
+
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:   getEnv("WEBHOOK_RETRY_BASE", "30s"),
+		WebhookTimeout:     getEnv("WEBHOOK_TIMEOUT", "10s"),
+
+		CaptchaProvider: getEnv("CAPTCHA_PROVIDER", ""),
+		CaptchaSecret:   getEnv("CAPTCHA_SECRET", ""),
+
+		MailSinkDir: getEnv("MAIL_SINK_DIR", ""),
+		MailFrom:    getEnv("MAIL_FROM", "XiverCMS <noreply@localhost>"),
+
+		Plugins: getEnvArray("PLUGINS", []string{}),
 	}
 
 	log.Println("Configuration loaded successfully")
@@ -57,6 +91,22 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func getEnvArray(key string, defaultValue []string) []string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -48,7 +48,9 @@ func Connect() {
 	log.Println("Database connected successfully")
 }
 
-func Migrate() {
+// Migrate creates and updates the tables of the built-in models and of extra models,
+// e.g. those registered by plugins
+func Migrate(extra ...interface{}) {
 	err := DB.AutoMigrate(
 		&models.User{},
 		&models.Role{},
@@ -61,7 +63,21 @@ func Migrate() {
 		&models.ContentHistory{},
 		&models.ContentRelation{},
 		&models.ComponentType{},
+		&models.Redirect{},
+		&models.SlugHistory{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+		&models.Release{},
+		&models.ReleaseAction{},
+		&models.PreviewToken{},
+		&models.Comment{},
+		&models.Notification{},
+		&models.EntryAccess{},
 	)
+	if err == nil && len(extra) > 0 {
+		err = DB.AutoMigrate(extra...)
+	}
 
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
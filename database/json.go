@@ -0,0 +1,58 @@
+package database
+
+import "regexp"
+
+var jsonKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsValidJSONKey reports whether key can be safely embedded in a JSON path expression
+func IsValidJSONKey(key string) bool {
+	return jsonKeyPattern.MatchString(key)
+}
+
+// JSONField returns a SQL expression that reads a top-level key of a JSON column as text.
+// Keys that are not plain identifiers yield NULL, so they never match anything.
+func JSONField(column, key string) string {
+	if !IsValidJSONKey(key) || !IsValidJSONKey(column) {
+		return "NULL"
+	}
+
+	if IsPostgres() {
+		return column + "->>'" + key + "'"
+	}
+	return "json_extract(" + column + ", '$." + key + "')"
+}
+
+// JSONText returns a SQL expression that reads a top-level key of a JSON column as text
+// the same way on every dialect: numbers as their decimal form, booleans as "true"/"false".
+func JSONText(column, key string) string {
+	if !IsValidJSONKey(key) || !IsValidJSONKey(column) {
+		return "NULL"
+	}
+
+	if IsPostgres() {
+		return column + "->>'" + key + "'"
+	}
+	path := "'$." + key + "'"
+	return "(CASE json_type(" + column + ", " + path + ") WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' " +
+		"ELSE CAST(json_extract(" + column + ", " + path + ") AS TEXT) END)"
+}
+
+// JSONNumber returns a SQL expression that reads a top-level key of a JSON column as a number
+func JSONNumber(column, key string) string {
+	if !IsValidJSONKey(key) || !IsValidJSONKey(column) {
+		return "NULL"
+	}
+
+	if IsPostgres() {
+		// Non-numeric values become NULL instead of failing the cast
+		field := column + "->>'" + key + "'"
+		return "(CASE WHEN " + field + " ~ '^-?[0-9]+(\\.[0-9]+)?$' THEN CAST(" + field + " AS DOUBLE PRECISION) END)"
+	}
+	path := "'$." + key + "'"
+	return "(CASE WHEN json_type(" + column + ", " + path + ") IN ('integer', 'real') THEN json_extract(" + column + ", " + path + ") END)"
+}
+
+// IsPostgres reports whether the database is PostgreSQL (SQLite otherwise)
+func IsPostgres() bool {
+	return DB != nil && DB.Dialector.Name() == "postgres"
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAggregateLimit = 100
+	maxAggregateLimit     = 1000
+)
+
+// aggregateMetrics are the metric query parameters, applied to comma-separated field lists
+var aggregateMetrics = []string{"sum", "avg", "min", "max"}
+
+// dateFieldTypes are schema field types that can be grouped into a date histogram
+var dateFieldTypes = map[string]bool{"date": true, "datetime": true}
+
+// numberFieldTypes are schema field types that support sum and avg
+var numberFieldTypes = map[string]bool{"number": true, "integer": true, "float": true, "decimal": true}
+
+// aggregateField is a metric on a field
+type aggregateField struct {
+	metric string
+	field  string
+	expr   string
+	alias  string
+}
+
+// PublicAggregateContentEntries returns aggregates over the published entries of a content type:
+// count, sum/avg/min/max of fields, groups by a field (or date histogram) and facets.
+// Handles /api/aggregate/{uid}; takes the same filters as /api/{uid}.
+func PublicAggregateContentEntries(c *gin.Context) {
+	contentTypeUID := c.Param("uid")
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ? AND is_visible = ?", contentTypeUID, true).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	// Check access
+	if !middleware.CheckContentTypeAccess(contentTypeUID, c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	cache.AddTags(c, cache.ContentTypeTag(contentTypeUID), cache.ListTag(contentTypeUID))
+
+	baseQuery := func() (*gorm.DB, error) {
+		query := database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ? AND status = ?", contentType.ID, "published")
+		query = currentEntryViewer(c).scope(query)
+		if search := c.Query("search"); search != "" {
+			query = query.Where("data LIKE ?", "%"+search+"%")
+		}
+		return applyEntryFilters(c, query, &contentType, true)
+	}
+
+	// Metrics: ?sum=price&avg=price&min=price,publishedAt&max=price
+	var metrics []aggregateField
+	for _, metric := range aggregateMetrics {
+		for _, fieldName := range splitList(c.Query(metric)) {
+			expr, err := aggregateExpr(&contentType, metric, fieldName)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			metrics = append(metrics, aggregateField{
+				metric: metric,
+				field:  fieldName,
+				expr:   expr,
+				alias:  fmt.Sprintf("m%d", len(metrics)),
+			})
+		}
+	}
+
+	selects := []string{"COUNT(*) AS count"}
+	for _, m := range metrics {
+		selects = append(selects, m.expr+" AS "+m.alias)
+	}
+
+	query, err := baseQuery()
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var totals []map[string]interface{}
+	if err := query.Select(strings.Join(selects, ", ")).Find(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := gin.H{"data": aggregateRow(totals[0], metrics)}
+
+	limit := defaultAggregateLimit
+	if value, err := strconv.Atoi(c.Query("limit")); err == nil && value > 0 {
+		limit = value
+		if limit > maxAggregateLimit {
+			limit = maxAggregateLimit
+		}
+	}
+
+	// Groups: ?groupBy=category or a date histogram ?groupBy=publishedAt&interval=month
+	if groupBy := c.Query("groupBy"); groupBy != "" {
+		keyExpr, histogram, err := groupKeyExpr(&contentType, groupBy, c.Query("interval"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query, _ := baseQuery()
+		order := "count DESC, group_key ASC"
+		if histogram {
+			order = "group_key ASC"
+		}
+
+		var rows []map[string]interface{}
+		if err := query.Select(keyExpr + " AS group_key, " + strings.Join(selects, ", ")).
+			Group(keyExpr).Order(order).Limit(limit).
+			Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		groups := make([]gin.H, len(rows))
+		for i, row := range rows {
+			groups[i] = aggregateRow(row, metrics)
+			groups[i]["key"] = aggregateValue(row["group_key"])
+		}
+		result["groups"] = groups
+	}
+
+	// Facets: distinct values with counts, ?facets=category,brand
+	if facetFields := splitList(c.Query("facets")); len(facetFields) > 0 {
+		facets := gin.H{}
+		for _, fieldName := range facetFields {
+			keyExpr, _, err := groupKeyExpr(&contentType, fieldName, "")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			query, _ := baseQuery()
+			var rows []map[string]interface{}
+			if err := query.Select(keyExpr + " AS value, COUNT(*) AS count").
+				Where(keyExpr + " IS NOT NULL").
+				Group(keyExpr).Order("count DESC, value ASC").Limit(limit).
+				Find(&rows).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			values := make([]gin.H, len(rows))
+			for i, row := range rows {
+				values[i] = gin.H{"value": aggregateValue(row["value"]), "count": aggregateValue(row["count"])}
+			}
+			facets[fieldName] = values
+		}
+		result["facets"] = facets
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// aggregateExpr returns the SQL aggregate of a metric on an entry column or schema field
+func aggregateExpr(contentType *models.ContentType, metric, fieldName string) (string, error) {
+	function := strings.ToUpper(metric)
+
+	if column, ok := entryColumns[fieldName]; ok {
+		if metric != "min" && metric != "max" {
+			return "", fmt.Errorf("%s is not supported on %s", metric, fieldName)
+		}
+		return function + "(" + column + ")", nil
+	}
+
+	fieldType, err := aggregateFieldType(contentType, fieldName)
+	if err != nil {
+		return "", err
+	}
+
+	if numberFieldTypes[fieldType] {
+		return function + "(" + database.JSONNumber("data", fieldName) + ")", nil
+	}
+	// Dates and text compare as text: ISO dates sort chronologically
+	if metric == "min" || metric == "max" {
+		return function + "(" + database.JSONText("data", fieldName) + ")", nil
+	}
+	return "", fmt.Errorf("%s requires a number field, %s is %s", metric, fieldName, fieldType)
+}
+
+// groupKeyExpr returns the SQL expression to group entries by a field. Date fields
+// with an interval (day, week, month, year) are grouped into a histogram.
+func groupKeyExpr(contentType *models.ContentType, fieldName, interval string) (string, bool, error) {
+	if column, ok := entryColumns[fieldName]; ok {
+		if column == "id" {
+			return "", false, fmt.Errorf("cannot group by id")
+		}
+		if interval == "" {
+			interval = "day"
+		}
+		expr, err := dateBucket(column, true, interval)
+		return expr, true, err
+	}
+
+	fieldType, err := aggregateFieldType(contentType, fieldName)
+	if err != nil {
+		return "", false, err
+	}
+
+	text := database.JSONText("data", fieldName)
+	if interval != "" {
+		if !dateFieldTypes[fieldType] {
+			return "", false, fmt.Errorf("interval requires a date field, %s is %s", fieldName, fieldType)
+		}
+		expr, err := dateBucket(text, false, interval)
+		return expr, true, err
+	}
+	return text, false, nil
+}
+
+// dateIntervals are histogram intervals: the prefix length of an ISO 8601 date and
+// the PostgreSQL format of a timestamp
+var dateIntervals = map[string]struct {
+	length   int
+	pgFormat string
+}{
+	"year":  {4, "YYYY"},
+	"month": {7, "YYYY-MM"},
+	"day":   {10, "YYYY-MM-DD"},
+}
+
+// dateBucket truncates a date to an interval. Entry columns are timestamps, data fields ISO
+// 8601 strings, which are truncated by prefix.
+func dateBucket(expr string, column bool, interval string) (string, error) {
+	if interval == "week" {
+		if !column {
+			return "", fmt.Errorf("week interval is supported on createdAt, updatedAt and publishedAt only")
+		}
+		if database.IsPostgres() {
+			return "to_char(" + expr + `, 'IYYY-"W"IW')`, nil
+		}
+		// SQLite has no ISO week format: the ISO year and week are those of the Thursday
+		// of the entry's Monday-Sunday week
+		thursday := "date(" + expr + ", 'weekday 0', '-3 days')"
+		return "printf('%s-W%02d', strftime('%Y', " + thursday + "), (strftime('%j', " + thursday + ") - 1) / 7 + 1)", nil
+	}
+
+	bucket, ok := dateIntervals[interval]
+	if !ok {
+		return "", fmt.Errorf("unknown interval %q, use day, week, month or year", interval)
+	}
+	if column && database.IsPostgres() {
+		return "to_char(" + expr + ", '" + bucket.pgFormat + "')", nil
+	}
+	return "substr(" + expr + ", 1, " + strconv.Itoa(bucket.length) + ")", nil
+}
+
+// aggregateFieldType returns the schema type of a field usable in aggregates
+func aggregateFieldType(contentType *models.ContentType, fieldName string) (string, error) {
+	fieldMap, ok := contentType.Schema[fieldName].(map[string]interface{})
+	if !ok || !database.IsValidJSONKey(fieldName) {
+		return "", fmt.Errorf("unknown field %s of %s", fieldName, contentType.UID)
+	}
+
+	fieldType, _ := fieldMap["type"].(string)
+	switch fieldType {
+	case "relation", "media", "component":
+		return "", fmt.Errorf("%s fields cannot be aggregated", fieldType)
+	}
+	return fieldType, nil
+}
+
+// aggregateRow formats the count and metrics of a result row
+func aggregateRow(row map[string]interface{}, metrics []aggregateField) gin.H {
+	formatted := gin.H{"count": aggregateValue(row["count"])}
+	for _, m := range metrics {
+		values, _ := formatted[m.metric].(gin.H)
+		if values == nil {
+			values = gin.H{}
+			formatted[m.metric] = values
+		}
+		values[m.field] = aggregateValue(row[m.alias])
+	}
+	return formatted
+}
+
+// aggregateValue normalizes values scanned from the database driver
+func aggregateValue(value interface{}) interface{} {
+	if raw, ok := value.([]byte); ok {
+		return string(raw)
+	}
+	return value
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/xivercms/xivercms/database"
 	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
 )
 
 func GetAuditLogs(c *gin.Context) {
@@ -72,6 +73,11 @@ func GetContentHistory(c *gin.Context) {
 
 // Helper function to create audit log
 func CreateAuditLog(c *gin.Context, action, subject string, subjectID *uint, description string, metadata map[string]interface{}) {
+	createAuditLog(database.DB, c, action, subject, subjectID, description, metadata)
+}
+
+// createAuditLog writes an audit log with db, e.g. inside the transaction of the logged change
+func createAuditLog(db *gorm.DB, c writeContext, action, subject string, subjectID *uint, description string, metadata map[string]interface{}) error {
 	var userID *uint
 	if userId, exists := c.Get("userId"); exists {
 		id := userId.(uint)
@@ -89,11 +95,16 @@ func CreateAuditLog(c *gin.Context, action, subject string, subjectID *uint, des
 		Metadata:    models.JSONB(metadata),
 	}
 
-	database.DB.Create(&log)
+	return db.Create(&log).Error
 }
 
 // Helper function to create content history entry
 func CreateContentHistory(entryID uint, changeType, changeNote string, data models.JSONB, changedByID *uint) {
+	createContentHistory(database.DB, entryID, changeType, changeNote, data, changedByID)
+}
+
+// createContentHistory writes a content history entry with db
+func createContentHistory(db *gorm.DB, entryID uint, changeType, changeNote string, data models.JSONB, changedByID *uint) error {
 	history := models.ContentHistory{
 		ContentEntryID: entryID,
 		Data:           data,
@@ -102,5 +113,5 @@ func CreateContentHistory(entryID uint, changeType, changeNote string, data mode
 		ChangedByID:    changedByID,
 	}
 
-	database.DB.Create(&history)
+	return db.Create(&history).Error
 }
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+)
+
+// cacheValidator builds ETag and Last-Modified values for public entry responses.
+// The ETag is derived from the content type, the normalized query string and the
+// IDs and UpdatedAt of every entry that is part of the response.
+type cacheValidator struct {
+	hash         hash.Hash
+	lastModified time.Time
+	collection   bool
+}
+
+func newCacheValidator(c *gin.Context, contentType *models.ContentType) *cacheValidator {
+	v := &cacheValidator{hash: sha1.New()}
+	fmt.Fprintf(v.hash, "%s|%d|%d|%s|", contentType.UID, contentType.ID, contentType.UpdatedAt.UnixNano(), c.Request.URL.Query().Encode())
+	v.touch(contentType.UpdatedAt)
+	return v
+}
+
+// addEntry adds an entry included in the response
+func (v *cacheValidator) addEntry(entry *models.ContentEntry) {
+	fmt.Fprintf(v.hash, "e%d:%d|", entry.ID, entry.UpdatedAt.UnixNano())
+	v.touch(entry.UpdatedAt)
+}
+
+// addCollection marks a list response of a content type. Its Last-Modified is the last
+// change to any entry of the type, including unpublished and deleted ones, so that it does
+// not move backwards when an entry leaves the list. Lists also depend on filters and access
+// lists, so only the ETag revalidates them; If-Modified-Since is ignored.
+func (v *cacheValidator) addCollection(contentType *models.ContentType) {
+	v.collection = true
+
+	var updated models.ContentEntry
+	database.DB.Unscoped().Select("id", "updated_at").Where("content_type_id = ?", contentType.ID).
+		Order("updated_at DESC").Limit(1).Find(&updated)
+	v.touch(updated.UpdatedAt)
+
+	var deleted models.ContentEntry
+	database.DB.Unscoped().Select("id", "deleted_at").Where("content_type_id = ? AND deleted_at IS NOT NULL", contentType.ID).
+		Order("deleted_at DESC").Limit(1).Find(&deleted)
+	if deleted.DeletedAt.Valid {
+		v.touch(deleted.DeletedAt.Time)
+	}
+}
+
+// addValue adds a response value that is not covered by entries, e.g. a total count
+func (v *cacheValidator) addValue(value interface{}) {
+	fmt.Fprintf(v.hash, "v%v|", value)
+}
+
+func (v *cacheValidator) touch(t time.Time) {
+	if t.After(v.lastModified) {
+		v.lastModified = t
+	}
+}
+
+func (v *cacheValidator) etag() string {
+	return `W/"` + hex.EncodeToString(v.hash.Sum(nil)) + `"`
+}
+
+// notModified writes the caching headers and answers 304 Not Modified when the
+// client's validators match. Returns true if the response has been written.
+func (v *cacheValidator) notModified(c *gin.Context, contentType *models.ContentType) bool {
+	etag := v.etag()
+	lastModified := v.lastModified.UTC().Truncate(time.Second)
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	c.Header("Cache-Control", cacheControl(contentType, isAuthenticatedRequest(c)))
+	c.Header("Vary", "Authorization, X-Preview-Token")
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		if cache.ETagMatches(ifNoneMatch, etag) {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+
+	if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince != "" && !v.collection {
+		if since, err := http.ParseTime(ifModifiedSince); err == nil && !lastModified.After(since) {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheControl builds the Cache-Control header from the content type's cache policy.
+// Responses to authenticated requests are private and never stored by shared caches.
+func cacheControl(contentType *models.ContentType, private bool) string {
+	directives := []string{"public"}
+	if private {
+		directives[0] = "private"
+	}
+
+	if contentType.CacheMaxAge <= 0 {
+		directives = append(directives, "no-cache")
+	} else {
+		directives = append(directives, "max-age="+strconv.Itoa(contentType.CacheMaxAge))
+	}
+	if !private && contentType.CacheSMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.Itoa(contentType.CacheSMaxAge))
+	}
+	if contentType.CacheStaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+strconv.Itoa(contentType.CacheStaleWhileRevalidate))
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+func isAuthenticatedRequest(c *gin.Context) bool {
+	if _, exists := c.Get("userId"); exists {
+		return true
+	}
+	return c.GetHeader("Authorization") != ""
+}
+
+// invalidateEntryCache drops cached public responses affected by writes to entries of a content type
+func invalidateEntryCache(contentTypeUID string, entryIDs ...uint) {
+	tags := []string{cache.ListTag(contentTypeUID)}
+	for _, id := range entryIDs {
+		tags = append(tags, cache.EntryTag(contentTypeUID, id))
+	}
+	cache.Invalidate(tags...)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+)
+
+// GetCacheStats returns response cache counters for monitoring
+func GetCacheStats(c *gin.Context) {
+	if cache.Default == nil {
+		c.JSON(http.StatusOK, cache.Stats{Enabled: false})
+		return
+	}
+
+	c.JSON(http.StatusOK, cache.Default.Stats())
+}
+
+// PurgeCache removes all cached responses
+func PurgeCache(c *gin.Context) {
+	if cache.Default != nil {
+		cache.Default.Purge()
+	}
+
+	CreateAuditLog(c, "purge", "cache", nil, "Purged response cache", nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cache purged successfully"})
+}
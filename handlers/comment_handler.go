@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+// maxCommentLength limits the body of a comment, in characters
+const maxCommentLength = 10000
+
+// mentionPattern finds @username mentions that are not part of an email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.\-]+)`)
+
+// GetComments returns the comment threads of an entry, oldest first, with their replies
+// URL: GET /api/admin/content-types/{uid}/entries/{id}/comments
+func GetComments(c *gin.Context) {
+	_, entry, ok := routeEntry(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("content_type_uid = ? AND entry_id = ? AND parent_id IS NULL", c.Param("uid"), entry.ID)
+
+	// Filter by field and resolved state
+	if field := c.Query("field"); field != "" {
+		query = query.Where("field = ?", field)
+	}
+	switch c.Query("resolved") {
+	case "true":
+		query = query.Where("resolved = ?", true)
+	case "false":
+		query = query.Where("resolved = ?", false)
+	}
+
+	var threads []models.Comment
+	if err := query.
+		Preload("Author").Preload("Mentions").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Replies.Author").Preload("Replies.Mentions").
+		Order("created_at ASC").
+		Find(&threads).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, threads)
+}
+
+type CreateCommentRequest struct {
+	Body     string `json:"body" binding:"required"`
+	Field    string `json:"field"`    // Schema field the thread is about, empty for the whole entry
+	ParentID *uint  `json:"parentId"` // Comment to reply to
+}
+
+// CreateComment starts a thread on an entry or replies to one. Replies to a reply join
+// its thread and always share the field of the thread.
+func CreateComment(c *gin.Context) {
+	contentType, entry, ok := routeEntry(c)
+	if !ok {
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, ok := commentBody(c, req.Body)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	comment := models.Comment{
+		ContentTypeUID: contentType.UID,
+		EntryID:        entry.ID,
+		Body:           body,
+		AuthorID:       userId.(uint),
+	}
+
+	if req.ParentID != nil {
+		var parent models.Comment
+		if err := database.DB.Where("id = ? AND content_type_uid = ? AND entry_id = ?", *req.ParentID, contentType.UID, entry.ID).
+			First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found on this entry"})
+			return
+		}
+		if parent.ParentID != nil {
+			comment.ParentID = parent.ParentID
+		} else {
+			comment.ParentID = &parent.ID
+		}
+		comment.Field = parent.Field
+	} else if req.Field != "" {
+		if _, exists := contentType.Schema[req.Field]; !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Field " + req.Field + " is not in the schema"})
+			return
+		}
+		comment.Field = req.Field
+	}
+
+	comment.Mentions = mentionedUsers(body)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := queueCommentNotifications(tx, c, &comment, comment.Mentions); err != nil {
+			return err
+		}
+		return createAuditLog(tx, c, "create", "comment", &comment.ID, "Commented on content entry", commentAuditMetadata(&comment))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	deliverNotifications(c)
+
+	respondComment(c, http.StatusCreated, comment.ID)
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// UpdateComment changes the body of a comment and its mentions. Only the author may edit.
+func UpdateComment(c *gin.Context) {
+	comment, ok := findComment(c)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	if comment.AuthorID != userId.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can edit a comment"})
+		return
+	}
+	if comment.Removed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment has been deleted"})
+		return
+	}
+
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, ok := commentBody(c, req.Body)
+	if !ok {
+		return
+	}
+
+	var previous []models.User
+	database.DB.Model(comment).Association("Mentions").Find(&previous)
+	wasMentioned := make(map[uint]bool, len(previous))
+	for _, user := range previous {
+		wasMentioned[user.ID] = true
+	}
+
+	mentions := mentionedUsers(body)
+	var added []models.User
+	for _, user := range mentions {
+		if !wasMentioned[user.ID] {
+			added = append(added, user)
+		}
+	}
+
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(comment).Updates(map[string]interface{}{"body": body, "edited_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(comment).Association("Mentions").Replace(mentions); err != nil {
+			return err
+		}
+
+		// Only users mentioned by the edit are notified
+		if len(added) > 0 {
+			comment.Body = body
+			if err := queueNotifications(tx, c, mentionNotifications(tx, comment, added)...); err != nil {
+				return err
+			}
+		}
+		return createAuditLog(tx, c, "update", "comment", &comment.ID, "Edited comment", commentAuditMetadata(comment))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	deliverNotifications(c)
+
+	respondComment(c, http.StatusOK, comment.ID)
+}
+
+// DeleteComment deletes a comment of the current user, or any comment with the manage
+// permission. A thread with replies keeps its first comment as a removed placeholder.
+func DeleteComment(c *gin.Context) {
+	comment, ok := findComment(c)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	if comment.AuthorID != userId.(uint) && !middleware.HasPermission(c, "manage", "comment") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can delete a comment"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var replies int64
+		if comment.ParentID == nil {
+			tx.Model(&models.Comment{}).Where("parent_id = ?", comment.ID).Count(&replies)
+		}
+
+		if replies > 0 {
+			if err := tx.Model(comment).Updates(map[string]interface{}{"body": "", "removed": true}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(comment).Association("Mentions").Clear(); err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Delete(comment).Error; err != nil {
+				return err
+			}
+			// A removed placeholder goes with the last reply of its thread
+			if comment.ParentID != nil {
+				var remaining int64
+				tx.Model(&models.Comment{}).Where("parent_id = ?", *comment.ParentID).Count(&remaining)
+				if remaining == 0 {
+					if err := tx.Where("id = ? AND removed = ?", *comment.ParentID, true).Delete(&models.Comment{}).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return createAuditLog(tx, c, "delete", "comment", &comment.ID, "Deleted comment", commentAuditMetadata(comment))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// ResolveComment marks a thread as resolved
+func ResolveComment(c *gin.Context) {
+	setCommentResolved(c, true)
+}
+
+// UnresolveComment reopens a resolved thread
+func UnresolveComment(c *gin.Context) {
+	setCommentResolved(c, false)
+}
+
+func setCommentResolved(c *gin.Context, resolved bool) {
+	comment, ok := findComment(c)
+	if !ok {
+		return
+	}
+	if comment.ParentID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only threads can be resolved, not replies"})
+		return
+	}
+
+	if comment.Resolved != resolved {
+		updates := map[string]interface{}{"resolved": resolved, "resolved_at": nil, "resolved_by_id": nil}
+		action, description := "unresolve", "Reopened comment thread"
+		if resolved {
+			userId, _ := c.Get("userId")
+			updates["resolved_at"] = time.Now()
+			updates["resolved_by_id"] = userId.(uint)
+			action, description = "resolve", "Resolved comment thread"
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(comment).Updates(updates).Error; err != nil {
+				return err
+			}
+			return createAuditLog(tx, c, action, "comment", &comment.ID, description, commentAuditMetadata(comment))
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	respondComment(c, http.StatusOK, comment.ID)
+}
+
+// findComment loads the comment of a route, which must belong to the entry in the URL
+func findComment(c *gin.Context) (*models.Comment, bool) {
+	var comment models.Comment
+	if err := database.DB.Where("id = ? AND content_type_uid = ? AND entry_id = ?", c.Param("commentId"), c.Param("uid"), c.Param("id")).
+		First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return nil, false
+	}
+	return &comment, true
+}
+
+// commentBody trims a comment body and checks its length, responding 400 if it is invalid
+func commentBody(c *gin.Context, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body cannot be empty"})
+		return "", false
+	}
+	if len([]rune(body)) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body is too long"})
+		return "", false
+	}
+	return body, true
+}
+
+// mentionedUsers returns the active users mentioned in a comment body. Mentions of
+// unknown usernames stay plain text.
+func mentionedUsers(body string) []models.User {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// Punctuation ending a sentence is not part of the username
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) == 0 {
+		return []models.User{}
+	}
+
+	var users []models.User
+	database.DB.Where("username IN ? AND is_active = ?", usernames, true).Find(&users)
+	return users
+}
+
+func commentAuditMetadata(comment *models.Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"contentType": comment.ContentTypeUID,
+		"entryId":     comment.EntryID,
+		"field":       comment.Field,
+		"parentId":    comment.ParentID,
+	}
+}
+
+func respondComment(c *gin.Context, status int, id uint) {
+	var comment models.Comment
+	if err := database.DB.Preload("Author").Preload("Mentions").First(&comment, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, comment)
+}
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"reflect"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
 	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/lifecycle"
 	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
 )
 
 func GetContentTypes(c *gin.Context) {
@@ -63,7 +69,17 @@ type CreateContentTypeRequest struct {
 	Description string                 `json:"description"`
 	IsVisible   bool                   `json:"isVisible"`
 	AccessType  string                 `json:"accessType"`
+	IsTree      bool                   `json:"isTree"`
+	URLPattern  string                 `json:"urlPattern"`
+	InSitemap   bool                   `json:"inSitemap"`
 	Schema      map[string]interface{} `json:"schema" binding:"required"`
+
+	CacheMaxAge               int `json:"cacheMaxAge"`
+	CacheSMaxAge              int `json:"cacheSMaxAge"`
+	CacheStaleWhileRevalidate int `json:"cacheStaleWhileRevalidate"`
+
+	Feed        models.FeedConfig       `json:"feed"`
+	Submissions models.SubmissionConfig `json:"submissions"`
 }
 
 type UpdateContentTypeRequest struct {
@@ -73,7 +89,18 @@ type UpdateContentTypeRequest struct {
 	Description string                 `json:"description"`
 	IsVisible   bool                   `json:"isVisible"`
 	AccessType  string                 `json:"accessType"`
+	IsTree      *bool                  `json:"isTree"` // Optional, keeps current value when omitted
+	URLPattern  string                 `json:"urlPattern"`
+	InSitemap   *bool                  `json:"inSitemap"` // Optional, keeps current value when omitted
 	Schema      map[string]interface{} `json:"schema"`
+
+	// Optional, keep current values when omitted
+	CacheMaxAge               *int `json:"cacheMaxAge"`
+	CacheSMaxAge              *int `json:"cacheSMaxAge"`
+	CacheStaleWhileRevalidate *int `json:"cacheStaleWhileRevalidate"`
+
+	Feed        *models.FeedConfig       `json:"feed"`        // Optional, replaces the feed settings
+	Submissions *models.SubmissionConfig `json:"submissions"` // Optional, replaces the submission settings
 }
 
 func CreateContentType(c *gin.Context) {
@@ -97,7 +124,17 @@ func CreateContentType(c *gin.Context) {
 		Description: req.Description,
 		IsVisible:   req.IsVisible,
 		AccessType:  req.AccessType,
+		IsTree:      req.IsTree,
+		URLPattern:  req.URLPattern,
+		InSitemap:   req.InSitemap,
 		Schema:      models.JSONB(req.Schema),
+
+		CacheMaxAge:               req.CacheMaxAge,
+		CacheSMaxAge:              req.CacheSMaxAge,
+		CacheStaleWhileRevalidate: req.CacheStaleWhileRevalidate,
+
+		Feed:        req.Feed,
+		Submissions: req.Submissions,
 	}
 
 	if contentType.Kind == "" {
@@ -107,11 +144,34 @@ func CreateContentType(c *gin.Context) {
 		contentType.AccessType = "public"
 	}
 
+	if err := validateFeedConfig(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if contentType.InSitemap && contentType.URLPattern == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "urlPattern is required to list entries in the sitemap"})
+		return
+	}
+	if err := validateSubmissionConfig(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateRelationSchema(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSlugSchema(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := database.DB.Create(&contentType).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	invalidateGraphQLSchema()
+
 	c.JSON(http.StatusCreated, contentType)
 }
 
@@ -142,22 +202,108 @@ func UpdateContentType(c *gin.Context) {
 	if req.AccessType != "" {
 		contentType.AccessType = req.AccessType
 	}
+	if req.IsTree != nil {
+		contentType.IsTree = *req.IsTree
+	}
+	if req.URLPattern != "" {
+		contentType.URLPattern = req.URLPattern
+	}
+	if req.InSitemap != nil {
+		contentType.InSitemap = *req.InSitemap
+	}
+	if req.CacheMaxAge != nil {
+		contentType.CacheMaxAge = *req.CacheMaxAge
+	}
+	if req.CacheSMaxAge != nil {
+		contentType.CacheSMaxAge = *req.CacheSMaxAge
+	}
+	if req.CacheStaleWhileRevalidate != nil {
+		contentType.CacheStaleWhileRevalidate = *req.CacheStaleWhileRevalidate
+	}
+	if req.Feed != nil {
+		contentType.Feed = *req.Feed
+	}
+	if req.Submissions != nil {
+		contentType.Submissions = *req.Submissions
+	}
 	contentType.IsVisible = req.IsVisible
 
+	if err := validateFeedConfig(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if contentType.InSitemap && contentType.URLPattern == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "urlPattern is required to list entries in the sitemap"})
+		return
+	}
+	if err := validateSubmissionConfig(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateRelationSchema(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSlugSchema(&contentType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := database.DB.Save(&contentType).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	cache.Invalidate(cache.ContentTypeTag(contentType.UID))
+	invalidateGraphQLSchema()
+	invalidateSitemap(contentType.UID)
+
 	c.JSON(http.StatusOK, contentType)
 }
 
 func DeleteContentType(c *gin.Context) {
 	uid := c.Param("uid")
-	if err := database.DB.Where("uid = ?", uid).Delete(&models.ContentType{}).Error; err != nil {
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", uid).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	// Entries are deleted with the type, applying the onDelete actions of relations pointing to them
+	var entryIDs []uint
+	database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ?", contentType.ID).Order("id ASC").Pluck("id", &entryIDs)
+
+	roots := make([]entryRef, len(entryIDs))
+	for i, id := range entryIDs {
+		roots[i] = entryRef{ContentTypeUID: uid, EntryID: id}
+	}
+
+	deletion, err := planEntryDeletion(database.DB, roots, uid)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if len(deletion.Blockers) > 0 {
+		deletion.respondBlocked(c)
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deletion.execute(tx, c); err != nil {
+			return err
+		}
+		return tx.Delete(&contentType).Error
+	}); err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+
+	deletion.invalidateCache()
+	cache.Invalidate(cache.ContentTypeTag(uid))
+	invalidateGraphQLSchema()
+	invalidateSitemap(uid)
+	notifyEntryEvents(c)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Content type deleted successfully"})
 }
@@ -185,6 +331,9 @@ func GetContentEntries(c *gin.Context) {
 		query = query.Where("status = ?", status)
 	}
 
+	// Filter by parent (tree-enabled content types)
+	query = applyParentFilter(c, query)
+
 	var total int64
 	query.Model(&models.ContentEntry{}).Count(&total)
 
@@ -226,36 +375,10 @@ func GetContentEntry(c *gin.Context) {
 		return
 	}
 
-	// Load relations if requested
+	// Load relations if requested (including inverse sides of bidirectional relations)
 	if c.Query("populate") == "true" {
-		var relations []models.ContentRelation
-		database.DB.Where("source_content_type_uid = ? AND source_entry_id = ?", contentTypeUID, entryID).Find(&relations)
-
-		// Populate related entries
-		populatedData := make(map[string]interface{})
-		for k, v := range entry.Data {
-			populatedData[k] = v
-		}
-
-		// Add relations to data
-		for _, relation := range relations {
-			var relatedEntry models.ContentEntry
-			var relatedContentType models.ContentType
-			database.DB.Where("uid = ?", relation.TargetContentTypeUID).First(&relatedContentType)
-			if err := database.DB.Where("content_type_id = ? AND id = ?", relatedContentType.ID, relation.TargetEntryID).First(&relatedEntry).Error; err == nil {
-				fieldName := relation.SourceFieldName
-				if relation.RelationType == "oneToMany" || relation.RelationType == "manyToMany" {
-					if populatedData[fieldName] == nil {
-						populatedData[fieldName] = []interface{}{}
-					}
-					arr := populatedData[fieldName].([]interface{})
-					populatedData[fieldName] = append(arr, relatedEntry)
-				} else {
-					populatedData[fieldName] = relatedEntry
-				}
-			}
-		}
-		entry.Data = populatedData
+		entry.Data = populateRelations(&entry, contentTypeUID, contentType.Schema, relatedEntryLoader(nil),
+			func(related *models.ContentEntry) interface{} { return *related })
 	}
 
 	c.JSON(http.StatusOK, entry)
@@ -264,14 +387,43 @@ func GetContentEntry(c *gin.Context) {
 type CreateContentEntryRequest struct {
 	Data   map[string]interface{} `json:"data" binding:"required"`
 	Status string                 `json:"status"`
+
+	// Tree placement, only used on create. Use MoveContentEntry to change it later.
+	ParentID *uint `json:"parentId"`
+	Position *int  `json:"position"`
 }
 
 func CreateContentEntry(c *gin.Context) {
-	contentTypeUID := c.Param("uid")
-	var contentType models.ContentType
+	contentType, err := findEntryContentType(c.Param("uid"))
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
 
-	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+	var req CreateContentEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := createEntry(c, contentType, &req)
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func UpdateContentEntry(c *gin.Context) {
+	contentType, err := findEntryContentType(c.Param("uid"))
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+	entry, err := findWritableEntry(contentType, c.Param("id"))
+	if err != nil {
+		respondEntryWriteError(c, err)
 		return
 	}
 
@@ -281,33 +433,114 @@ func CreateContentEntry(c *gin.Context) {
 		return
 	}
 
-	userId, _ := c.Get("userId")
-	userID := userId.(uint)
+	if err := updateEntry(c, contentType, entry, &req); err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
 
-	// Separate relation fields from regular data
-	entryData := make(map[string]interface{})
-	relationData := make(map[string]interface{})
+	c.JSON(http.StatusOK, entry)
+}
+
+func DeleteContentEntry(c *gin.Context) {
+	contentType, err := findEntryContentType(c.Param("uid"))
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+	entry, err := findWritableEntry(contentType, c.Param("id"))
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+
+	deleted, err := deleteEntry(c, contentType, entry)
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted successfully", "deleted": deleted})
+}
+
+// entryRequestError is an entry write refused before anything was written, e.g. for an
+// unknown entry or invalid relations
+type entryRequestError struct {
+	status   int
+	message  string
+	fields   map[string]string
+	blockers []deleteBlocker
+}
+
+func (e *entryRequestError) Error() string { return e.message }
 
-	for k, v := range req.Data {
-		// Check if this is a relation field
-		schemaMap := map[string]interface{}(contentType.Schema)
-		if fieldDef, exists := schemaMap[k]; exists {
-			if fieldMap, ok := fieldDef.(map[string]interface{}); ok {
-				if fieldType, _ := fieldMap["type"].(string); fieldType == "relation" {
-					relationData[k] = v
-					continue
-				}
+// findEntryContentType loads the content type of an entry write
+func findEntryContentType(uid string) (*models.ContentType, error) {
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", uid).First(&contentType).Error; err != nil {
+		return nil, &entryRequestError{status: http.StatusNotFound, message: "Content type not found"}
+	}
+	return &contentType, nil
+}
+
+// findWritableEntry loads an entry of a content type for an update or delete
+func findWritableEntry(contentType *models.ContentType, id interface{}) (*models.ContentEntry, error) {
+	var entry models.ContentEntry
+	if err := database.DB.Where("id = ? AND content_type_id = ?", id, contentType.ID).First(&entry).Error; err != nil {
+		return nil, &entryRequestError{status: http.StatusNotFound, message: "Entry not found"}
+	}
+	return &entry, nil
+}
+
+// entryWriterID is the user an entry write is recorded for, nil for API tokens
+func entryWriterID(c writeContext) *uint {
+	if userId, exists := c.Get("userId"); exists {
+		if userID, ok := userId.(uint); ok {
+			return &userID
+		}
+	}
+	return nil
+}
+
+// splitRelationData separates the relation fields of request data from the other fields
+func splitRelationData(schema models.JSONB, data map[string]interface{}) (fields, relations map[string]interface{}) {
+	fields = make(map[string]interface{})
+	relations = make(map[string]interface{})
+	for k, v := range data {
+		if fieldMap, ok := schema[k].(map[string]interface{}); ok {
+			if fieldType, _ := fieldMap["type"].(string); fieldType == "relation" {
+				relations[k] = v
+				continue
 			}
 		}
-		entryData[k] = v
+		fields[k] = v
+	}
+	return fields, relations
+}
+
+// createEntry creates an entry as the admin API does: the request is validated, and the
+// entry, its relations, audit log, history and webhooks are written in one transaction.
+// Used by the REST handler and GraphQL mutations.
+func createEntry(c *gin.Context, contentType *models.ContentType, req *CreateContentEntryRequest) (*models.ContentEntry, error) {
+	contentTypeUID := contentType.UID
+	userID := entryWriterID(c)
+
+	// Separate relation fields from regular data
+	entryData, relationData := splitRelationData(contentType.Schema, req.Data)
+
+	if fieldErrors := validateRelations(contentTypeUID, 0, relationData, contentType.Schema); fieldErrors != nil {
+		return nil, &entryRequestError{status: http.StatusBadRequest, message: "Invalid relations", fields: fieldErrors}
+	}
+
+	if fieldErrors := validatePluginFields(contentType.Schema, entryData); fieldErrors != nil {
+		return nil, &entryRequestError{status: http.StatusBadRequest, message: "Invalid fields", fields: fieldErrors}
 	}
 
 	entry := models.ContentEntry{
 		ContentTypeID: contentType.ID,
 		Data:          models.JSONB(entryData),
 		Status:        req.Status,
-		CreatedByID:   &userID,
-		UpdatedByID:   &userID,
+		CreatedByID:   userID,
+		UpdatedByID:   userID,
 	}
 
 	if entry.Status == "" {
@@ -319,54 +552,90 @@ func CreateContentEntry(c *gin.Context) {
 		entry.PublishedAt = &now
 	}
 
-	if err := database.DB.Create(&entry).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if err := validateTreeParent(database.DB, contentType, 0, req.ParentID); err != nil {
+		return nil, &entryRequestError{status: http.StatusBadRequest, message: err.Error()}
 	}
+	entry.ParentID = req.ParentID
 
-	database.DB.Preload("CreatedBy").Preload("UpdatedBy").First(&entry, entry.ID)
+	// The entry, its relations, audit log and history are written together
+	var relationTags []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Slugs are checked for uniqueness within the transaction of the write
+		if err := applySlugFields(tx, contentType, 0, entry.Data, req.Data); err != nil {
+			return err
+		}
+		if err := runBeforeWriteHooks(tx, c, lifecycle.BeforeCreateAction, contentType, &entry, nil); err != nil {
+			return err
+		}
+		if entry.Status == "published" && entry.PublishedAt == nil {
+			now := time.Now()
+			entry.PublishedAt = &now
+		}
 
-	// Process relations if any
-	if len(relationData) > 0 {
-		processRelations(c, contentTypeUID, entry.ID, relationData, contentType.Schema)
-	}
+		if contentType.IsTree {
+			entry.Position = nextTreePosition(tx, contentType.ID, entry.ParentID)
+		}
 
-	// Create audit log
-	CreateAuditLog(c, "create", "content-entry", &entry.ID, "Created content entry", map[string]interface{}{
-		"contentType": contentTypeUID,
-		"status":      entry.Status,
-	})
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
 
-	// Create content history
-	CreateContentHistory(entry.ID, "created", "Entry created", entry.Data, entry.CreatedByID)
+		if contentType.IsTree && req.Position != nil {
+			if err := placeInSiblings(tx, &entry, req.Position); err != nil {
+				return err
+			}
+		}
 
-	c.JSON(http.StatusCreated, entry)
-}
+		// Process relations if any
+		tags, err := processRelations(tx, contentTypeUID, entry.ID, relationData, contentType.Schema)
+		if err != nil {
+			return err
+		}
+		relationTags = tags
+
+		// Create audit log
+		if err := createAuditLog(tx, c, "create", "content-entry", &entry.ID, "Created content entry", map[string]interface{}{
+			"contentType": contentTypeUID,
+			"status":      entry.Status,
+		}); err != nil {
+			return err
+		}
 
-func UpdateContentEntry(c *gin.Context) {
-	contentTypeUID := c.Param("uid")
-	entryID := c.Param("id")
+		// Create content history
+		if err := createContentHistory(tx, entry.ID, "created", "Entry created", entry.Data, entry.CreatedByID); err != nil {
+			return err
+		}
 
-	var contentType models.ContentType
-	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
-		return
-	}
+		if err := runLifecycleHooks(tx, c, lifecycle.AfterCreateAction, contentType, &entry, nil); err != nil {
+			return err
+		}
 
-	var entry models.ContentEntry
-	if err := database.DB.Where("id = ? AND content_type_id = ?", entryID, contentType.ID).First(&entry).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
-		return
+		events := append([]string{webhooks.EntryCreate}, statusWebhookEvents("", entry.Status)...)
+		return queueEntryEvents(tx, c, contentType, &entry, events...)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	var req CreateContentEntryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	database.DB.Preload("CreatedBy").Preload("UpdatedBy").First(&entry, entry.ID)
+
+	invalidateEntryCache(contentTypeUID, entry.ID)
+	cache.Invalidate(relationTags...)
+	notifyEntryEvents(c)
+
+	return &entry, nil
+}
+
+// updateEntry applies an update request to a loaded entry as the admin API does, see
+// createEntry. entry holds the stored state afterwards.
+func updateEntry(c *gin.Context, contentType *models.ContentType, entry *models.ContentEntry, req *CreateContentEntryRequest) error {
+	contentTypeUID := contentType.UID
 
 	// Separate relation fields from regular data
 	relationData := make(map[string]interface{})
+	previousData := entry.Data
+	previousStatus := entry.Status
+	previous := *entry
 
 	if req.Data != nil {
 		// Get current data
@@ -378,19 +647,21 @@ func UpdateContentEntry(c *gin.Context) {
 		}
 
 		// Merge with new data
-		for k, v := range req.Data {
-			// Check if this is a relation field
-			schemaMap := map[string]interface{}(contentType.Schema)
-			if fieldDef, exists := schemaMap[k]; exists {
-				if fieldMap, ok := fieldDef.(map[string]interface{}); ok {
-					if fieldType, _ := fieldMap["type"].(string); fieldType == "relation" {
-						relationData[k] = v
-						continue
-					}
-				}
-			}
+		fields, relations := splitRelationData(contentType.Schema, req.Data)
+		for k, v := range fields {
 			currentData[k] = v
 		}
+		relationData = relations
+
+		if fieldErrors := validateRelations(contentTypeUID, entry.ID, relationData, contentType.Schema); fieldErrors != nil {
+			return &entryRequestError{status: http.StatusBadRequest, message: "Invalid relations", fields: fieldErrors}
+		}
+
+		// Only the fields being written are checked, stored values were valid when written
+		if fieldErrors := validatePluginFields(contentType.Schema, req.Data); fieldErrors != nil {
+			return &entryRequestError{status: http.StatusBadRequest, message: "Invalid fields", fields: fieldErrors}
+		}
+
 		entry.Data = models.JSONB(currentData)
 	}
 
@@ -403,152 +674,251 @@ func UpdateContentEntry(c *gin.Context) {
 		}
 	}
 
-	userId, _ := c.Get("userId")
-	userID := userId.(uint)
-	entry.UpdatedByID = &userID
-
-	if err := database.DB.Save(&entry).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	userID := entryWriterID(c)
+	entry.UpdatedByID = userID
 
-	database.DB.Preload("CreatedBy").Preload("UpdatedBy").First(&entry, entry.ID)
-
-	// Process relations if any
-	if len(relationData) > 0 {
-		processRelations(c, contentTypeUID, entry.ID, relationData, contentType.Schema)
-	}
-
-	// Create audit log
 	changeType := "updated"
 	if req.Status == "published" && entry.PublishedAt != nil {
 		changeType = "published"
 	}
-	CreateAuditLog(c, "update", "content-entry", &entry.ID, "Updated content entry", map[string]interface{}{
-		"contentType": contentTypeUID,
-		"status":      entry.Status,
-	})
 
-	// Create content history
-	CreateContentHistory(entry.ID, changeType, "Entry updated", entry.Data, entry.UpdatedByID)
+	// The entry, its relations, slug history, audit log and history are written together
+	var relationTags []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Slugs are checked for uniqueness within the transaction of the write
+		if req.Data != nil {
+			if err := applySlugFields(tx, contentType, entry.ID, entry.Data, req.Data); err != nil {
+				return err
+			}
+		}
+		if err := runBeforeWriteHooks(tx, c, lifecycle.BeforeUpdateAction, contentType, entry, &previous); err != nil {
+			return err
+		}
+		if entry.Status == "published" && entry.PublishedAt == nil {
+			now := time.Now()
+			entry.PublishedAt = &now
+		}
 
-	c.JSON(http.StatusOK, entry)
-}
+		if err := tx.Save(entry).Error; err != nil {
+			return err
+		}
 
-func DeleteContentEntry(c *gin.Context) {
-	contentTypeUID := c.Param("uid")
-	entryID := c.Param("id")
+		// Keep old slugs resolvable
+		if err := recordSlugChanges(tx, contentType, entry, previousData, userID); err != nil {
+			return err
+		}
 
-	var contentType models.ContentType
-	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
-		return
-	}
+		// Process relations if any
+		tags, err := processRelations(tx, contentTypeUID, entry.ID, relationData, contentType.Schema)
+		if err != nil {
+			return err
+		}
+		relationTags = tags
+
+		// Create audit log
+		if err := createAuditLog(tx, c, "update", "content-entry", &entry.ID, "Updated content entry", map[string]interface{}{
+			"contentType": contentTypeUID,
+			"status":      entry.Status,
+		}); err != nil {
+			return err
+		}
 
-	if err := database.DB.Where("id = ? AND content_type_id = ?", entryID, contentType.ID).
-		Delete(&models.ContentEntry{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		// Create content history
+		if err := createContentHistory(tx, entry.ID, changeType, "Entry updated", entry.Data, entry.UpdatedByID); err != nil {
+			return err
+		}
+
+		if err := runLifecycleHooks(tx, c, lifecycle.AfterUpdateAction, contentType, entry, &previous); err != nil {
+			return err
+		}
+
+		events := append([]string{webhooks.EntryUpdate}, statusWebhookEvents(previousStatus, entry.Status)...)
+		return queueEntryEvents(tx, c, contentType, entry, events...)
+	})
+	if err != nil {
+		return err
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted successfully"})
+	database.DB.Preload("CreatedBy").Preload("UpdatedBy").First(entry, entry.ID)
+
+	invalidateEntryCache(contentTypeUID, entry.ID)
+	cache.Invalidate(relationTags...)
+	notifyEntryEvents(c)
+
+	return nil
 }
 
-// processRelations processes relation fields and creates ContentRelation records
-func processRelations(c *gin.Context, contentTypeUID string, entryID uint, data map[string]interface{}, schema models.JSONB) {
-	if schema == nil {
-		return
+// deleteEntry deletes an entry with the onDelete actions of the relations pointing to it
+// applied and returns every deleted entry. A restrict relation refuses the delete with 409.
+func deleteEntry(c *gin.Context, contentType *models.ContentType, entry *models.ContentEntry) ([]entryRef, error) {
+	// Apply the onDelete actions of relations pointing to the entry
+	deletion, err := planEntryDeletion(database.DB, []entryRef{{ContentTypeUID: contentType.UID, EntryID: entry.ID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(deletion.Blockers) > 0 {
+		return nil, deletion.blockedError()
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deletion.execute(tx, c); err != nil {
+			return err
+		}
+		return createAuditLog(tx, c, "delete", "content-entry", &entry.ID, "Deleted content entry", map[string]interface{}{
+			"contentType": contentType.UID,
+			"deleted":     deletion.deleted(),
+		})
+	}); err != nil {
+		return nil, err
 	}
 
-	// Get schema as map
-	schemaMap := map[string]interface{}(schema)
+	deletion.invalidateCache()
+	notifyEntryEvents(c)
+
+	return deletion.deleted(), nil
+}
+
+// processRelations processes relation fields and creates ContentRelation records.
+// Returns the cache tags of entries whose relations changed.
+func processRelations(db *gorm.DB, contentTypeUID string, entryID uint, data map[string]interface{}, schema models.JSONB) ([]string, error) {
+	var tags []string
 
-	// Process each field in data
 	for fieldName, fieldValue := range data {
 		// Check if field is defined in schema as relation
-		fieldDef, exists := schemaMap[fieldName]
-		if !exists {
-			continue
-		}
-
-		fieldMap, ok := fieldDef.(map[string]interface{})
-		if !ok {
+		field, ok := relationField(schema, fieldName)
+		if !ok || field.TargetContentType == "" {
 			continue
 		}
 
-		fieldType, _ := fieldMap["type"].(string)
-		if fieldType != "relation" {
-			continue
-		}
+		targetIDs := relationTargetIDs(fieldValue, isToManyRelation(field.RelationType))
 
-		// Get relation configuration
-		targetContentType, _ := fieldMap["targetContentType"].(string)
-		relationType, _ := fieldMap["relationType"].(string)
-		if targetContentType == "" {
+		// Inverse side: the relations are stored on the owning entries
+		if field.MappedBy != "" {
+			inverseTags, err := setInverseRelations(db, contentTypeUID, entryID, field, targetIDs)
+			if err != nil {
+				return nil, err
+			}
+			tags = append(tags, inverseTags...)
 			continue
 		}
 
 		// Delete existing relations for this field
-		database.DB.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
-			contentTypeUID, entryID, fieldName).Delete(&models.ContentRelation{})
+		var existing []models.ContentRelation
+		if err := db.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+			contentTypeUID, entryID, fieldName).Find(&existing).Error; err != nil {
+			return nil, err
+		}
+		for _, relation := range existing {
+			tags = append(tags, cache.EntryTag(relation.TargetContentTypeUID, relation.TargetEntryID))
+		}
+		if err := db.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+			contentTypeUID, entryID, fieldName).Delete(&models.ContentRelation{}).Error; err != nil {
+			return nil, err
+		}
 
 		// Create new relations
-		if relationType == "oneToMany" || relationType == "manyToMany" {
-			// Handle array of IDs
-			if ids, ok := fieldValue.([]interface{}); ok {
-				for idx, id := range ids {
-					var targetID uint
-					if idFloat, ok := id.(float64); ok {
-						targetID = uint(idFloat)
-					} else if idMap, ok := id.(map[string]interface{}); ok {
-						if idVal, exists := idMap["id"]; exists {
-							if idFloat, ok := idVal.(float64); ok {
-								targetID = uint(idFloat)
-							}
-						}
-					}
-
-					if targetID > 0 {
-						relation := models.ContentRelation{
-							SourceContentTypeUID: contentTypeUID,
-							SourceEntryID:        entryID,
-							SourceFieldName:      fieldName,
-							TargetContentTypeUID: targetContentType,
-							TargetEntryID:        targetID,
-							RelationType:         relationType,
-							Order:                idx,
-						}
-						database.DB.Create(&relation)
-					}
-				}
+		for idx, targetID := range targetIDs {
+			relation := models.ContentRelation{
+				SourceContentTypeUID: contentTypeUID,
+				SourceEntryID:        entryID,
+				SourceFieldName:      fieldName,
+				TargetContentTypeUID: field.TargetContentType,
+				TargetEntryID:        targetID,
+				RelationType:         field.RelationType,
+				Order:                idx,
 			}
-		} else {
-			// Handle single ID
-			var targetID uint
-			if idFloat, ok := fieldValue.(float64); ok {
-				targetID = uint(idFloat)
-			} else if idMap, ok := fieldValue.(map[string]interface{}); ok {
-				if idVal, exists := idMap["id"]; exists {
-					if idFloat, ok := idVal.(float64); ok {
-						targetID = uint(idFloat)
-					}
-				}
-			}
-
-			if targetID > 0 {
-				relation := models.ContentRelation{
-					SourceContentTypeUID: contentTypeUID,
-					SourceEntryID:        entryID,
-					SourceFieldName:      fieldName,
-					TargetContentTypeUID: targetContentType,
-					TargetEntryID:        targetID,
-					RelationType:         relationType,
-				}
-				database.DB.Create(&relation)
+			if err := db.Create(&relation).Error; err != nil {
+				return nil, err
 			}
+			tags = append(tags, cache.EntryTag(field.TargetContentType, targetID))
 		}
 
 		// Relation is stored separately in ContentRelation table
 		// Field is already excluded from entry.Data
 	}
+
+	return tags, nil
+}
+
+// runLifecycleHooks runs the lifecycle hooks of an entry write inside its transaction
+func runLifecycleHooks(tx *gorm.DB, c writeContext, action lifecycle.Action, contentType *models.ContentType, entry, previous *models.ContentEntry) error {
+	event := &lifecycle.Event{
+		Action:      action,
+		ContentType: contentType,
+		Entry:       entry,
+		Previous:    previous,
+		Tx:          tx,
+		Context:     writeRequestContext(c),
+	}
+	if userId, exists := c.Get("userId"); exists {
+		if userID, ok := userId.(uint); ok {
+			event.UserID = &userID
+		}
+	}
+	return lifecycle.Run(event)
+}
+
+// runBeforeWriteHooks runs the BeforeCreate or BeforeUpdate hooks of an entry write and
+// checks the data they changed the way request data is checked: plugin fields are
+// validated, slug fields normalized and kept unique within the transaction
+func runBeforeWriteHooks(tx *gorm.DB, c writeContext, action lifecycle.Action, contentType *models.ContentType, entry, previous *models.ContentEntry) error {
+	before := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		before[k] = v
+	}
+
+	if err := runLifecycleHooks(tx, c, action, contentType, entry, previous); err != nil {
+		return err
+	}
+
+	changed := make(map[string]interface{})
+	for k, v := range entry.Data {
+		if previousValue, ok := before[k]; !ok || !reflect.DeepEqual(previousValue, v) {
+			changed[k] = v
+		}
+	}
+	if fieldErrors := validatePluginFields(contentType.Schema, changed); fieldErrors != nil {
+		return lifecycle.Reject("Invalid fields", fieldErrors)
+	}
+
+	if entry.Data == nil {
+		entry.Data = models.JSONB{}
+	}
+	return applySlugFields(tx, contentType, entry.ID, entry.Data, changed)
+}
+
+// respondEntryWriteError answers a failed entry write, see entryWriteErrorResponse
+func respondEntryWriteError(c *gin.Context, err error) {
+	c.JSON(entryWriteErrorResponse(err))
+}
+
+// entryWriteErrorResponse is the status and body of a failed entry write: the status of a
+// refused request, 400 when a lifecycle hook rejected it or a slug is invalid, 409 when a
+// slug is used by another entry
+func entryWriteErrorResponse(err error) (int, gin.H) {
+	var refused *entryRequestError
+	if errors.As(err, &refused) {
+		response := gin.H{"error": refused.message}
+		if len(refused.fields) > 0 {
+			response["fields"] = refused.fields
+		}
+		if len(refused.blockers) > 0 {
+			response["blockers"] = refused.blockers
+		}
+		return refused.status, response
+	}
+
+	if errors.Is(err, errSlugTaken) || errors.Is(err, errInvalidSlug) {
+		return slugErrorStatus(err), gin.H{"error": err.Error()}
+	}
+
+	var rejected *lifecycle.ValidationError
+	if errors.As(err, &rejected) {
+		response := gin.H{"error": rejected.Message}
+		if len(rejected.Fields) > 0 {
+			response["fields"] = rejected.Fields
+		}
+		return http.StatusBadRequest, response
+	}
+	return http.StatusInternalServerError, gin.H{"error": err.Error()}
 }
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+// entryViewerKey caches the entry viewer of a request
+const entryViewerKey = "entryViewer"
+
+// entryViewer is who reads entries through the public API. Restricted entries are
+// visible to the roles and users in their access list; every viewer has the Public role,
+// logged-in viewers also have their own roles.
+type entryViewer struct {
+	userID     uint // 0 for anonymous clients
+	roleIDs    []uint
+	superAdmin bool
+}
+
+// currentEntryViewer returns the entry viewer of a request
+func currentEntryViewer(c *gin.Context) *entryViewer {
+	if cached, ok := c.Get(entryViewerKey); ok {
+		if viewer, ok := cached.(*entryViewer); ok && viewer != nil {
+			return viewer
+		}
+	}
+
+	viewer := anonymousEntryViewer()
+	if userId, exists := c.Get("userId"); exists {
+		var user models.User
+		if err := database.DB.Preload("Roles").First(&user, userId).Error; err == nil {
+			viewer.userID, viewer.superAdmin = user.ID, user.IsSuperAdmin
+			for _, role := range user.Roles {
+				viewer.roleIDs = append(viewer.roleIDs, role.ID)
+			}
+		}
+	}
+
+	c.Set(entryViewerKey, viewer)
+	return viewer
+}
+
+// anonymousEntryViewer is a client without a login, e.g. a search engine crawler
+func anonymousEntryViewer() *entryViewer {
+	viewer := &entryViewer{}
+	var public models.Role
+	if err := database.DB.Where("name = ?", "Public").First(&public).Error; err == nil {
+		viewer.roleIDs = []uint{public.ID}
+	}
+	return viewer
+}
+
+// grants selects the IDs of the restricted entries the viewer may read
+func (v *entryViewer) grants() *gorm.DB {
+	grants := database.DB.Model(&models.EntryAccess{}).Select("content_entry_id")
+	if v.userID != 0 {
+		return grants.Where("user_id = ? OR role_id IN ?", v.userID, v.roleIDs)
+	}
+	return grants.Where("role_id IN ?", v.roleIDs)
+}
+
+// scope limits a content entry query to the entries the viewer may read, so that counts
+// and pagination leave out restricted entries as well
+func (v *entryViewer) scope(query *gorm.DB) *gorm.DB {
+	if v.superAdmin {
+		return query
+	}
+	return query.Where(database.DB.Where("content_entries.restricted = ?", false).
+		Or("content_entries.id IN (?)", v.grants()))
+}
+
+// allows reports whether the viewer may read a loaded entry
+func (v *entryViewer) allows(entry *models.ContentEntry) bool {
+	return !entry.Restricted || v.allowsID(entry.ID)
+}
+
+// allowsID reports whether the viewer is in the access list of a restricted entry
+func (v *entryViewer) allowsID(entryID uint) bool {
+	if v.superAdmin {
+		return true
+	}
+	var count int64
+	v.grants().Where("content_entry_id = ?", entryID).Count(&count)
+	return count > 0
+}
+
+// GetEntryAccess returns the access list of an entry
+// URL: GET /api/admin/content-types/{uid}/entries/{id}/access
+func GetEntryAccess(c *gin.Context) {
+	_, entry, ok := routeEntry(c)
+	if !ok {
+		return
+	}
+
+	var grants []models.EntryAccess
+	if err := database.DB.Where("content_entry_id = ?", entry.ID).
+		Preload("Role").Preload("User").
+		Order("id ASC").
+		Find(&grants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entryAccessResponse(entry, grants))
+}
+
+type UpdateEntryAccessRequest struct {
+	RoleIDs []uint `json:"roleIds"`
+	UserIDs []uint `json:"userIds"`
+}
+
+// UpdateEntryAccess replaces the access list of an entry. An empty list makes the entry
+// readable by everyone with access to its content type again.
+func UpdateEntryAccess(c *gin.Context) {
+	contentType, entry, ok := routeEntry(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateEntryAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	roleIDs, userIDs := uniqueIDs(req.RoleIDs), uniqueIDs(req.UserIDs)
+
+	var count int64
+	if len(roleIDs) > 0 {
+		database.DB.Model(&models.Role{}).Where("id IN ?", roleIDs).Count(&count)
+		if int(count) != len(roleIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found"})
+			return
+		}
+	}
+	if len(userIDs) > 0 {
+		database.DB.Model(&models.User{}).Where("id IN ?", userIDs).Count(&count)
+		if int(count) != len(userIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+			return
+		}
+	}
+
+	grants := make([]models.EntryAccess, 0, len(roleIDs)+len(userIDs))
+	for i := range roleIDs {
+		grants = append(grants, models.EntryAccess{ContentEntryID: entry.ID, RoleID: &roleIDs[i]})
+	}
+	for i := range userIDs {
+		grants = append(grants, models.EntryAccess{ContentEntryID: entry.ID, UserID: &userIDs[i]})
+	}
+	restricted := len(grants) > 0
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_entry_id = ?", entry.ID).Delete(&models.EntryAccess{}).Error; err != nil {
+			return err
+		}
+		if restricted {
+			if err := tx.Create(&grants).Error; err != nil {
+				return err
+			}
+		}
+		// Only the flag changes, the entry itself is not modified
+		if err := tx.Model(entry).UpdateColumn("restricted", restricted).Error; err != nil {
+			return err
+		}
+		return createAuditLog(tx, c, "update", "entry-access", &entry.ID, "Updated entry access list", map[string]interface{}{
+			"contentType": contentType.UID,
+			"roleIds":     roleIDs,
+			"userIds":     userIDs,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invalidateEntryCache(contentType.UID, entry.ID)
+	invalidateSitemap(contentType.UID)
+
+	database.DB.Where("content_entry_id = ?", entry.ID).Preload("Role").Preload("User").Order("id ASC").Find(&grants)
+	entry.Restricted = restricted
+	c.JSON(http.StatusOK, entryAccessResponse(entry, grants))
+}
+
+func entryAccessResponse(entry *models.ContentEntry, grants []models.EntryAccess) gin.H {
+	roles := make([]gin.H, 0)
+	users := make([]map[string]interface{}, 0)
+	for _, grant := range grants {
+		if grant.Role != nil {
+			roles = append(roles, gin.H{"id": grant.Role.ID, "name": grant.Role.Name})
+		}
+		if grant.User != nil {
+			users = append(users, safeUserResponse(grant.User))
+		}
+	}
+	return gin.H{"restricted": entry.Restricted, "roles": roles, "users": users}
+}
+
+// routeEntry loads the content type and entry of an admin entry route, responding 404
+// if either does not exist
+func routeEntry(c *gin.Context) (*models.ContentType, *models.ContentEntry, bool) {
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", c.Param("uid")).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return nil, nil, false
+	}
+
+	var entry models.ContentEntry
+	if err := database.DB.Where("id = ? AND content_type_id = ?", c.Param("id"), contentType.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return nil, nil, false
+	}
+	return &contentType, &entry, true
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/config"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+	feedGenerator    = "XiverCMS"
+)
+
+// feedItem is an entry mapped through the feed configuration, independent of the format
+type feedItem struct {
+	ID        string
+	URL       string
+	Title     string
+	Summary   string
+	Content   string
+	Author    string
+	Published time.Time
+	Updated   time.Time
+}
+
+// feed is a content type feed independent of the format
+type feed struct {
+	Title       string
+	Description string
+	HomeURL     string
+	FeedURL     string
+	Updated     time.Time
+	Items       []feedItem
+}
+
+// PublicGetFeed returns the latest published entries of a content type as a feed.
+// URL: /api/feeds/{uid}/{format}, format is rss, atom or json
+func PublicGetFeed(c *gin.Context) {
+	contentTypeUID := c.Param("uid")
+	format := c.Param("format")
+	if format != "rss" && format != "atom" && format != "json" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown feed format, use rss, atom or json"})
+		return
+	}
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ? AND is_visible = ?", contentTypeUID, true).First(&contentType).Error; err != nil || !contentType.Feed.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	if !middleware.CheckContentTypeAccess(contentTypeUID, c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	cache.AddTags(c, cache.ContentTypeTag(contentTypeUID), cache.ListTag(contentTypeUID))
+
+	limit := contentType.Feed.Limit
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+
+	var entries []models.ContentEntry
+	if err := currentEntryViewer(c).scope(database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ? AND status = ?", contentType.ID, "published")).
+		Order("published_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	validator := newCacheValidator(c, &contentType)
+	validator.addCollection(&contentType)
+	validator.addValue(format)
+	// Feed and item URLs depend on the host the feed was requested from
+	validator.addValue(requestBaseURL(c))
+	validator.addValue(siteBaseURL(c))
+	for i := range entries {
+		validator.addEntry(&entries[i])
+	}
+	if validator.notModified(c, &contentType) {
+		return
+	}
+
+	f := buildFeed(c, &contentType, entries)
+
+	switch format {
+	case "rss":
+		writeFeedXML(c, "application/rss+xml; charset=utf-8", rssFeed(f))
+	case "atom":
+		writeFeedXML(c, "application/atom+xml; charset=utf-8", atomFeed(f))
+	default:
+		body, err := json.Marshal(jsonFeed(f))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "application/feed+json; charset=utf-8", body)
+	}
+}
+
+func buildFeed(c *gin.Context, contentType *models.ContentType, entries []models.ContentEntry) *feed {
+	siteURL := siteBaseURL(c)
+	settings := contentType.Feed
+
+	f := &feed{
+		Title:       settings.Title,
+		Description: settings.Description,
+		HomeURL:     siteURL + "/",
+		FeedURL:     requestBaseURL(c) + c.Request.URL.Path,
+		Updated:     contentType.UpdatedAt,
+		Items:       make([]feedItem, 0, len(entries)),
+	}
+	if f.Title == "" {
+		f.Title = contentType.DisplayName
+	}
+	if f.Description == "" {
+		f.Description = contentType.Description
+	}
+	if f.Description == "" {
+		f.Description = f.Title
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		data := serializePluginFields(contentType.Schema, entry.Data)
+
+		item := feedItem{
+			ID:      fmt.Sprintf("urn:xivercms:%s:%d", contentType.UID, entry.ID),
+			Title:   feedText(data[settings.Fields.Title]),
+			Summary: feedText(data[settings.Fields.Summary]),
+			Content: feedText(data[settings.Fields.Content]),
+			Author:  feedText(data[settings.Fields.Author]),
+			Updated: entry.UpdatedAt,
+		}
+
+		if path := renderURLPattern(contentType.URLPattern, entry); path != "" {
+			item.URL = siteURL + path
+		} else {
+			item.URL = requestBaseURL(c) + "/api/" + contentType.UID + "/" + strconv.FormatUint(uint64(entry.ID), 10)
+		}
+
+		if entry.PublishedAt != nil {
+			item.Published = *entry.PublishedAt
+		} else {
+			item.Published = entry.CreatedAt
+		}
+		if settings.Fields.Date != "" {
+			if date, ok := feedDate(data[settings.Fields.Date]); ok {
+				item.Published = date
+			}
+		}
+
+		if item.Title == "" {
+			item.Title = fmt.Sprintf("%s #%d", contentType.DisplayName, entry.ID)
+		}
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	return f
+}
+
+// feedText returns a field value as text, empty for values that are not text or numbers
+func feedText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// feedDate parses a date or datetime field value
+func feedDate(value interface{}) (time.Time, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// siteBaseURL is the frontend origin entry URLs are built on: SITE_URL or the API's own origin
+func siteBaseURL(c *gin.Context) string {
+	if config.AppConfig.SiteURL != "" {
+		return strings.TrimRight(config.AppConfig.SiteURL, "/")
+	}
+	return requestBaseURL(c)
+}
+
+// requestBaseURL is the scheme and host the request was made to, honouring the headers of
+// trusted proxies
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := middleware.ForwardedHeader(c, "X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := c.Request.Host
+	if forwarded := middleware.ForwardedHeader(c, "X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
+
+// validateFeedConfig checks that the feed fields exist in the schema
+func validateFeedConfig(contentType *models.ContentType) error {
+	settings := contentType.Feed
+	if settings.Limit < 0 || settings.Limit > maxFeedLimit {
+		return fmt.Errorf("feed limit must be at most %d", maxFeedLimit)
+	}
+
+	fields := map[string]string{
+		"title":   settings.Fields.Title,
+		"summary": settings.Fields.Summary,
+		"content": settings.Fields.Content,
+		"author":  settings.Fields.Author,
+		"date":    settings.Fields.Date,
+	}
+	for role, name := range fields {
+		if name == "" {
+			continue
+		}
+		if _, ok := contentType.Schema[name]; !ok {
+			return fmt.Errorf("feed %s field %s is not in the schema", role, name)
+		}
+		if _, isRelation := relationField(contentType.Schema, name); isRelation {
+			return fmt.Errorf("feed %s field %s cannot be a relation", role, name)
+		}
+	}
+	return nil
+}
+
+func writeFeedXML(c *gin.Context, contentType string, document interface{}) {
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), body...))
+}
+
+// RSS 2.0
+
+type rssDocument struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	AtomNS       string     `xml:"xmlns:atom,attr"`
+	ContentNS    string     `xml:"xmlns:content,attr"`
+	DublinCoreNS string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Description string  `xml:"description,omitempty"`
+	Content     string  `xml:"content:encoded,omitempty"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+func rssFeed(f *feed) *rssDocument {
+	doc := &rssDocument{
+		Version:      "2.0",
+		AtomNS:       "http://www.w3.org/2005/Atom",
+		ContentNS:    "http://purl.org/rss/1.0/modules/content/",
+		DublinCoreNS: "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.HomeURL,
+			Description:   f.Description,
+			SelfLink:      rssLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Generator:     feedGenerator,
+		},
+	}
+	for _, item := range f.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: false, Value: item.ID},
+			Description: item.Summary,
+			Content:     item.Content,
+			Creator:     item.Author,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return doc
+}
+
+// Atom
+
+type atomDocument struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	ID        string      `xml:"id"`
+	Links     []atomLink  `xml:"link"`
+	Updated   string      `xml:"updated"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Summary   *atomText   `xml:"summary,omitempty"`
+	Content   *atomText   `xml:"content,omitempty"`
+}
+
+func atomFeed(f *feed) *atomDocument {
+	doc := &atomDocument{
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.FeedURL,
+		Links: []atomLink{
+			{Href: f.HomeURL, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		Updated:   f.Updated.UTC().Format(time.RFC3339),
+		Generator: feedGenerator,
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Link:      atomLink{Href: item.URL, Rel: "alternate"},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
+
+// JSON Feed 1.1
+
+func jsonFeed(f *feed) gin.H {
+	items := make([]gin.H, 0, len(f.Items))
+	for _, item := range f.Items {
+		jsonItem := gin.H{
+			"id":             item.ID,
+			"url":            item.URL,
+			"title":          item.Title,
+			"date_published": item.Published.UTC().Format(time.RFC3339),
+			"date_modified":  item.Updated.UTC().Format(time.RFC3339),
+		}
+		if item.Summary != "" {
+			jsonItem["summary"] = item.Summary
+		}
+		// An item needs content, the summary stands in for it
+		if item.Content != "" {
+			jsonItem["content_html"] = item.Content
+		} else {
+			jsonItem["content_text"] = item.Summary
+		}
+		if item.Author != "" {
+			jsonItem["authors"] = []gin.H{{"name": item.Author}}
+		}
+		items = append(items, jsonItem)
+	}
+
+	return gin.H{
+		"version":       "https://jsonfeed.org/version/1.1",
+		"title":         f.Title,
+		"description":   f.Description,
+		"home_page_url": f.HomeURL,
+		"feed_url":      f.FeedURL,
+		"items":         items,
+	}
+}
@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/plugins"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxFilterRelationDepth limits how many relations a filter path may follow
+	maxFilterRelationDepth = 2
+	// maxFilters limits the number of filter conditions per request
+	maxFilters = 20
+	// maxFilterValues limits the values of a $in/$notIn condition
+	maxFilterValues = 100
+)
+
+// entryColumns are entry attributes that can be filtered besides the schema fields
+var entryColumns = map[string]string{
+	"id":          "id",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+	"publishedAt": "published_at",
+}
+
+var filterOperators = map[string]bool{
+	"$eq":       true,
+	"$ne":       true,
+	"$in":       true,
+	"$notIn":    true,
+	"$contains": true,
+	"$gt":       true,
+	"$gte":      true,
+	"$lt":       true,
+	"$lte":      true,
+	"$null":     true,
+}
+
+var filterKeyPattern = regexp.MustCompile(`\[([^\[\]]*)\]`)
+
+// entryFilter is a condition from the query string:
+// filters[field]=value, filters[field][$op]=value or, following relations,
+// filters[relation][field][$op]=value
+type entryFilter struct {
+	Path   []string
+	Op     string
+	Values []string
+}
+
+// filterError is an invalid or forbidden filter
+type filterError struct {
+	status  int
+	message string
+}
+
+func (e *filterError) Error() string { return e.message }
+
+func badFilter(format string, args ...interface{}) error {
+	return &filterError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// filterErrorStatus returns the HTTP status for an error of applyEntryFilters
+func filterErrorStatus(err error) int {
+	if fe, ok := err.(*filterError); ok {
+		return fe.status
+	}
+	return http.StatusInternalServerError
+}
+
+// parseEntryFilters reads the filters[...] parameters of a query string
+func parseEntryFilters(query url.Values) ([]entryFilter, error) {
+	keys := make([]string, 0)
+	for key := range query {
+		if strings.HasPrefix(key, "filters[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if len(keys) > maxFilters {
+		return nil, badFilter("at most %d filters are allowed", maxFilters)
+	}
+
+	filters := make([]entryFilter, 0, len(keys))
+	for _, key := range keys {
+		matches := filterKeyPattern.FindAllStringSubmatch(key[len("filters"):], -1)
+		consumed := 0
+		path := make([]string, 0, len(matches))
+		for _, match := range matches {
+			consumed += len(match[0])
+			path = append(path, match[1])
+		}
+		if consumed != len(key)-len("filters") || len(path) == 0 {
+			return nil, badFilter("invalid filter %q", key)
+		}
+
+		filter := entryFilter{Op: "$eq"}
+		if last := path[len(path)-1]; strings.HasPrefix(last, "$") {
+			if !filterOperators[last] && !plugins.IsFilterOperator(last) {
+				return nil, badFilter("unknown filter operator %s", last)
+			}
+			filter.Op = last
+			path = path[:len(path)-1]
+		}
+		if len(path) == 0 {
+			return nil, badFilter("invalid filter %q", key)
+		}
+		for _, segment := range path {
+			if segment == "" {
+				return nil, badFilter("invalid filter %q", key)
+			}
+		}
+		filter.Path = path
+
+		// $in and $notIn take repeated parameters or a comma-separated list
+		for _, value := range query[key] {
+			if filter.Op == "$in" || filter.Op == "$notIn" {
+				filter.Values = append(filter.Values, strings.Split(value, ",")...)
+			} else {
+				filter.Values = append(filter.Values, value)
+			}
+		}
+		if len(filter.Values) > maxFilterValues {
+			return nil, badFilter("at most %d values are allowed in %s", maxFilterValues, key)
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// applyEntryFilters adds the filters of the request to a query on content entries.
+// Filters may follow relation fields (up to maxFilterRelationDepth) to the related entries'
+// attributes; the related content types must be accessible to the caller. With
+// publishedOnly only published related entries match.
+func applyEntryFilters(c *gin.Context, query *gorm.DB, contentType *models.ContentType, publishedOnly bool) (*gorm.DB, error) {
+	return applyEntryFilterValues(c, c.Request.URL.Query(), query, contentType, publishedOnly)
+}
+
+// applyEntryFilterValues is applyEntryFilters with the filters[...] parameters taken from values
+func applyEntryFilterValues(c *gin.Context, values url.Values, query *gorm.DB, contentType *models.ContentType, publishedOnly bool) (*gorm.DB, error) {
+	filters, err := parseEntryFilters(values)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filter := range filters {
+		condition, args, err := filterCondition(c, contentType, filter.Path, filter.Op, filter.Values, publishedOnly, 0)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+
+	return query, nil
+}
+
+// filterCondition builds a SQL condition on content_entries for a filter path
+func filterCondition(c *gin.Context, contentType *models.ContentType, path []string, op string, values []string, publishedOnly bool, depth int) (string, []interface{}, error) {
+	fieldName := path[0]
+
+	field, isRelation := relationField(contentType.Schema, fieldName)
+	if !isRelation {
+		if len(path) > 1 {
+			return "", nil, badFilter("%s is not a relation field of %s", fieldName, contentType.UID)
+		}
+		return attributeCondition(contentType, fieldName, op, values)
+	}
+
+	if depth >= maxFilterRelationDepth {
+		return "", nil, badFilter("filters may follow at most %d relations", maxFilterRelationDepth)
+	}
+
+	var targetType models.ContentType
+	if err := database.DB.Where("uid = ?", field.TargetContentType).First(&targetType).Error; err != nil {
+		return "", nil, badFilter("target content type of %s not found", fieldName)
+	}
+	if (publishedOnly && !targetType.IsVisible) || !middleware.CheckContentTypeAccess(targetType.UID, c) {
+		return "", nil, &filterError{status: http.StatusForbidden, message: fmt.Sprintf("Access denied to filter on %s", fieldName)}
+	}
+
+	// Cached responses depend on the related entries as well
+	cache.AddTags(c, cache.ListTag(targetType.UID))
+
+	// Related entries of the target content type
+	targets := database.DB.Model(&models.ContentEntry{}).Select("id").Where("content_type_id = ?", targetType.ID)
+	if publishedOnly {
+		targets = currentEntryViewer(c).scope(targets.Where("status = ?", "published"))
+	}
+
+	// filters[relation][$null]=true matches entries without related entries
+	isNull := false
+	if len(path) == 1 && op == "$null" {
+		null, err := parseFilterBool(values)
+		if err != nil {
+			return "", nil, err
+		}
+		isNull = null
+	} else {
+		rest := path[1:]
+		if len(rest) == 0 {
+			// A relation compared directly matches on the related entry ID
+			rest = []string{"id"}
+		}
+
+		condition, args, err := filterCondition(c, &targetType, rest, op, values, publishedOnly, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		targets = targets.Where(condition, args...)
+	}
+
+	// Owning side stores the relation, the inverse side reads it from the owner
+	var related *gorm.DB
+	if field.MappedBy == "" {
+		related = database.DB.Model(&models.ContentRelation{}).Select("source_entry_id").
+			Where("source_content_type_uid = ? AND source_field_name = ? AND target_content_type_uid = ?", contentType.UID, field.Name, targetType.UID).
+			Where("target_entry_id IN (?)", targets)
+	} else {
+		related = database.DB.Model(&models.ContentRelation{}).Select("target_entry_id").
+			Where("source_content_type_uid = ? AND source_field_name = ? AND target_content_type_uid = ?", targetType.UID, field.MappedBy, contentType.UID).
+			Where("source_entry_id IN (?)", targets)
+	}
+
+	if isNull {
+		return "id NOT IN (?)", []interface{}{related}, nil
+	}
+	return "id IN (?)", []interface{}{related}, nil
+}
+
+// attributeCondition builds a condition on an entry column or a schema field of entry data
+func attributeCondition(contentType *models.ContentType, fieldName, op string, values []string) (string, []interface{}, error) {
+	if len(values) == 0 {
+		return "", nil, badFilter("filter on %s has no value", fieldName)
+	}
+
+	if column, ok := entryColumns[fieldName]; ok {
+		return columnCondition(column, fieldName, op, values)
+	}
+
+	if _, ok := contentType.Schema[fieldName]; !ok || !database.IsValidJSONKey(fieldName) {
+		return "", nil, badFilter("unknown field %s of %s", fieldName, contentType.UID)
+	}
+
+	text := database.JSONText("data", fieldName)
+	number := database.JSONNumber("data", fieldName)
+
+	// Operators of plugin field types
+	if condition, args, ok, err := pluginFilterCondition(contentType.Schema, fieldName, op, values, text, number); ok || err != nil {
+		return condition, args, err
+	}
+
+	switch op {
+	case "$eq":
+		return text + " = ?", []interface{}{values[0]}, nil
+	case "$ne":
+		return "(" + text + " <> ? OR " + text + " IS NULL)", []interface{}{values[0]}, nil
+	case "$in":
+		return text + " IN ?", []interface{}{values}, nil
+	case "$notIn":
+		return "(" + text + " NOT IN ? OR " + text + " IS NULL)", []interface{}{values}, nil
+	case "$contains":
+		return "LOWER(" + text + ") LIKE ?", []interface{}{"%" + strings.ToLower(values[0]) + "%"}, nil
+	case "$null":
+		null, err := parseFilterBool(values)
+		if err != nil {
+			return "", nil, err
+		}
+		if null {
+			return text + " IS NULL", nil, nil
+		}
+		return text + " IS NOT NULL", nil, nil
+	}
+
+	// $gt, $gte, $lt, $lte compare numbers
+	if comparisonOperators[op] == "" {
+		return "", nil, badFilter("%s is not supported on %s", op, fieldName)
+	}
+	value, err := strconv.ParseFloat(values[0], 64)
+	if err != nil {
+		return "", nil, badFilter("%s on %s requires a number", op, fieldName)
+	}
+	return number + " " + comparisonOperators[op] + " ?", []interface{}{value}, nil
+}
+
+var comparisonOperators = map[string]string{
+	"$eq":  "=",
+	"$ne":  "<>",
+	"$gt":  ">",
+	"$gte": ">=",
+	"$lt":  "<",
+	"$lte": "<=",
+}
+
+// columnCondition builds a condition on the id or a timestamp column of entries
+func columnCondition(column, fieldName, op string, values []string) (string, []interface{}, error) {
+	if op == "$null" {
+		null, err := parseFilterBool(values)
+		if err != nil {
+			return "", nil, err
+		}
+		if null {
+			return column + " IS NULL", nil, nil
+		}
+		return column + " IS NOT NULL", nil, nil
+	}
+	if op == "$contains" || !filterOperators[op] {
+		return "", nil, badFilter("%s is not supported on %s", op, fieldName)
+	}
+
+	parsed := make([]interface{}, len(values))
+	for i, value := range values {
+		var err error
+		if column == "id" {
+			parsed[i], err = strconv.ParseUint(value, 10, 32)
+		} else {
+			parsed[i], err = parseFilterTime(value)
+		}
+		if err != nil {
+			return "", nil, badFilter("invalid value %q for %s", value, fieldName)
+		}
+	}
+
+	switch op {
+	case "$in":
+		return column + " IN ?", []interface{}{parsed}, nil
+	case "$notIn":
+		return column + " NOT IN ?", []interface{}{parsed}, nil
+	}
+	return column + " " + comparisonOperators[op] + " ?", []interface{}{parsed[0]}, nil
+}
+
+func parseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func parseFilterBool(values []string) (bool, error) {
+	null, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, badFilter("$null requires true or false")
+	}
+	return null, nil
+}
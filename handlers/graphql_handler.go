@@ -0,0 +1,597 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/plugins"
+)
+
+const (
+	defaultGraphQLPageSize = 10
+	maxGraphQLPageSize     = 100
+	// maxGraphQLDepth limits how deeply selections may nest, e.g. posts { data { author { books { title } } } }
+	// has depth 5 and follows two relations, as many as a filter may
+	maxGraphQLDepth = 5
+	// maxGraphQLFields limits the fields a request selects, counting fragments once per use
+	maxGraphQLFields = 300
+)
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphQLContextKey struct{}
+
+// GraphQL executes a query against the schema generated from the visible content types.
+// Queries read published entries with the access rules of the public API, mutations
+// require a signed-in user or an API token and write through the same functions as the
+// admin entry handlers. GET accepts queries only.
+func GraphQL(c *gin.Context) {
+	var req GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variables"})
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is required"})
+		return
+	}
+
+	if c.Request.Method == http.MethodGet && isGraphQLMutation(req.Query, req.OperationName) {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Mutations require POST"})
+		return
+	}
+
+	// Every nested relation runs a query per entry, so large documents are refused before
+	// executing them
+	if err := checkGraphQLCost(req.Query, req.OperationName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schema, err := currentGraphQLSchema(middleware.ContentTypeAccessLevel(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         *schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(c.Request.Context(), graphQLContextKey{}, c),
+	})
+
+	// Requests that fail parsing or validation are not executed: no data, no error path
+	status := http.StatusOK
+	if result.Data == nil && result.HasErrors() && len(result.Errors[0].Path) == 0 {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, result)
+}
+
+// isGraphQLMutation reports whether the operation to execute is a mutation
+func isGraphQLMutation(query, operationName string) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok || operation.Operation != ast.OperationTypeMutation {
+			continue
+		}
+		if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGraphQLCost rejects operations nesting deeper than maxGraphQLDepth or selecting more
+// than maxGraphQLFields fields. Introspection fields are not counted, they do not load
+// entries. Documents that do not parse are left to graphql.Do to report.
+func checkGraphQLCost(query, operationName string) error {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	fields := 0
+	var walk func(set *ast.SelectionSet, depth int, spread map[string]bool) error
+	walk = func(set *ast.SelectionSet, depth int, spread map[string]bool) error {
+		if set == nil {
+			return nil
+		}
+		for _, selection := range set.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				if selection.Name == nil || strings.HasPrefix(selection.Name.Value, "__") {
+					continue
+				}
+				if depth > maxGraphQLDepth {
+					return fmt.Errorf("query may nest at most %d levels", maxGraphQLDepth)
+				}
+				if fields++; fields > maxGraphQLFields {
+					return fmt.Errorf("query may select at most %d fields", maxGraphQLFields)
+				}
+				if err := walk(selection.SelectionSet, depth+1, spread); err != nil {
+					return err
+				}
+			case *ast.InlineFragment:
+				if err := walk(selection.SelectionSet, depth, spread); err != nil {
+					return err
+				}
+			case *ast.FragmentSpread:
+				// Cycles are reported by validation
+				fragment, ok := fragments[selection.Name.Value]
+				if !ok || spread[fragment.Name.Value] {
+					continue
+				}
+				spread[fragment.Name.Value] = true
+				err := walk(fragment.SelectionSet, depth, spread)
+				delete(spread, fragment.Name.Value)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+		if err := walk(operation.SelectionSet, 1, make(map[string]bool)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func graphQLGinContext(p graphql.ResolveParams) *gin.Context {
+	return p.Context.Value(graphQLContextKey{}).(*gin.Context)
+}
+
+// graphQLError is a failed operation; extensions carry the status and details of the REST error
+type graphQLError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e *graphQLError) Error() string { return e.message }
+
+func (e *graphQLError) Extensions() map[string]interface{} { return e.extensions }
+
+func newGraphQLError(status int, message string) error {
+	return &graphQLError{message: message, extensions: map[string]interface{}{"status": status}}
+}
+
+func resolveGraphQLEntries(p graphql.ResolveParams, contentType *models.ContentType) (interface{}, error) {
+	c := graphQLGinContext(p)
+	if !middleware.CheckContentTypeAccess(contentType.UID, c) {
+		return nil, newGraphQLError(http.StatusForbidden, "Access denied")
+	}
+
+	page, _ := p.Args["page"].(int)
+	pageSize, _ := p.Args["pageSize"].(int)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultGraphQLPageSize
+	}
+	if pageSize > maxGraphQLPageSize {
+		pageSize = maxGraphQLPageSize
+	}
+
+	query := database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ? AND status = ?", contentType.ID, "published")
+	query = currentEntryViewer(c).scope(query)
+
+	if search, _ := p.Args["search"].(string); search != "" {
+		query = query.Where("data LIKE ?", "%"+search+"%")
+	}
+
+	if filters := p.Args["filters"]; filters != nil {
+		values := url.Values{}
+		if err := graphQLFilterValues(values, "filters", filters); err != nil {
+			return nil, err
+		}
+		filtered, err := applyEntryFilterValues(c, values, query, contentType, true)
+		if err != nil {
+			return nil, newGraphQLError(filterErrorStatus(err), err.Error())
+		}
+		query = filtered
+	}
+
+	sort, _ := p.Args["sort"].(string)
+	order, err := graphQLSortOrder(contentType, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var entries []*models.ContentEntry
+	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Order(order).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"data": entries,
+		"meta": map[string]interface{}{
+			"page":     page,
+			"pageSize": pageSize,
+			"total":    total,
+		},
+	}, nil
+}
+
+func resolveGraphQLEntry(p graphql.ResolveParams, contentType *models.ContentType) (interface{}, error) {
+	c := graphQLGinContext(p)
+	if !middleware.CheckContentTypeAccess(contentType.UID, c) {
+		return nil, newGraphQLError(http.StatusForbidden, "Access denied")
+	}
+
+	id, err := graphQLEntryID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	var entry models.ContentEntry
+	if err := database.DB.Where("id = ? AND content_type_id = ? AND status = ?", id, contentType.ID, "published").
+		First(&entry).Error; err != nil || !currentEntryViewer(c).allows(&entry) {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// resolveGraphQLRelation returns the published entries related through a relation field,
+// in stored order. Inverse fields are read from the owning side.
+func resolveGraphQLRelation(p graphql.ResolveParams, contentTypeUID string, field models.ContentField) (interface{}, error) {
+	c := graphQLGinContext(p)
+	if !middleware.CheckContentTypeAccess(field.TargetContentType, c) {
+		return nil, newGraphQLError(http.StatusForbidden, fmt.Sprintf("Access denied to %s", field.Name))
+	}
+
+	entry := p.Source.(*models.ContentEntry)
+
+	var relations []models.ContentRelation
+	var refs []entryRef
+	if field.MappedBy == "" {
+		if err := fieldRelations(database.DB, contentTypeUID, entry.ID, field.Name).Find(&relations).Error; err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			refs = append(refs, entryRef{ContentTypeUID: relation.TargetContentTypeUID, EntryID: relation.TargetEntryID})
+		}
+	} else {
+		if err := database.DB.Where("source_content_type_uid = ? AND source_field_name = ? AND target_content_type_uid = ? AND target_entry_id = ?",
+			field.TargetContentType, field.MappedBy, contentTypeUID, entry.ID).
+			Order("source_entry_id ASC").Find(&relations).Error; err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			refs = append(refs, entryRef{ContentTypeUID: relation.SourceContentTypeUID, EntryID: relation.SourceEntryID})
+		}
+	}
+
+	load := relatedEntryLoader(currentEntryViewer(c))
+	related := make([]*models.ContentEntry, 0, len(refs))
+	for _, ref := range refs {
+		if relatedEntry, ok := load(ref.ContentTypeUID, ref.EntryID); ok {
+			related = append(related, relatedEntry)
+		}
+	}
+
+	if isToManyRelation(field.RelationType) {
+		return related, nil
+	}
+	if len(related) == 0 {
+		return nil, nil
+	}
+	return related[0], nil
+}
+
+func resolveGraphQLCreate(p graphql.ResolveParams, contentType *models.ContentType) (interface{}, error) {
+	c := graphQLGinContext(p)
+	if err := checkGraphQLWriteAccess(c); err != nil {
+		return nil, err
+	}
+
+	data, err := graphQLInputData(p.Args["data"], contentType.Schema)
+	if err != nil {
+		return nil, err
+	}
+	req := CreateContentEntryRequest{Data: data}
+	if status, ok := p.Args["status"].(string); ok {
+		req.Status = status
+	}
+	if parentID, ok := p.Args["parentId"]; ok {
+		id, err := graphQLEntryID(parentID)
+		if err != nil {
+			return nil, err
+		}
+		req.ParentID = &id
+	}
+	if position, ok := p.Args["position"].(int); ok {
+		req.Position = &position
+	}
+
+	current, err := findEntryContentType(contentType.UID)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	entry, err := createEntry(c, current, &req)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	return entry, nil
+}
+
+func resolveGraphQLUpdate(p graphql.ResolveParams, contentType *models.ContentType) (interface{}, error) {
+	c := graphQLGinContext(p)
+	if err := checkGraphQLWriteAccess(c); err != nil {
+		return nil, err
+	}
+
+	id, err := graphQLEntryID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	data, err := graphQLInputData(p.Args["data"], contentType.Schema)
+	if err != nil {
+		return nil, err
+	}
+	req := CreateContentEntryRequest{Data: data}
+	if status, ok := p.Args["status"].(string); ok {
+		req.Status = status
+	}
+
+	current, err := findEntryContentType(contentType.UID)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	entry, err := findWritableEntry(current, id)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	if err := updateEntry(c, current, entry, &req); err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	return entry, nil
+}
+
+func resolveGraphQLDelete(p graphql.ResolveParams, contentType *models.ContentType) (interface{}, error) {
+	c := graphQLGinContext(p)
+	if err := checkGraphQLWriteAccess(c); err != nil {
+		return nil, err
+	}
+
+	id, err := graphQLEntryID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := findEntryContentType(contentType.UID)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	entry, err := findWritableEntry(current, id)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	deleted, err := deleteEntry(c, current, entry)
+	if err != nil {
+		return nil, graphQLEntryWriteError(err)
+	}
+	return deleted, nil
+}
+
+// checkGraphQLWriteAccess lets the clients of the admin entry routes run mutations: signed-in
+// users and API tokens that are not read-only
+func checkGraphQLWriteAccess(c *gin.Context) error {
+	if _, exists := c.Get("userId"); exists {
+		return nil
+	}
+	if _, exists := c.Get("apiTokenId"); exists {
+		if c.GetString("apiTokenType") == "read-only" {
+			return newGraphQLError(http.StatusForbidden, "Read-only token cannot perform this action")
+		}
+		return nil
+	}
+	return newGraphQLError(http.StatusUnauthorized, "Authentication required")
+}
+
+// graphQLEntryWriteError turns a failed entry write into a GraphQL error carrying the
+// status and details of the REST response
+func graphQLEntryWriteError(err error) error {
+	status, response := entryWriteErrorResponse(err)
+	message, _ := response["error"].(string)
+	extensions := map[string]interface{}{"status": status}
+	for _, key := range []string{"fields", "blockers"} {
+		if value, ok := response[key]; ok {
+			extensions[key] = value
+		}
+	}
+	return &graphQLError{message: message, extensions: extensions}
+}
+
+// graphQLInputData converts mutation input to entry data: relation IDs become numbers
+// as in REST request bodies
+func graphQLInputData(input interface{}, schema models.JSONB) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	fields, _ := input.(map[string]interface{})
+	for name, value := range fields {
+		if name == "_" {
+			continue
+		}
+
+		field, isRelation := relationField(schema, name)
+		if !isRelation || value == nil {
+			data[name] = value
+			continue
+		}
+
+		if !isToManyRelation(field.RelationType) {
+			id, err := graphQLEntryID(value)
+			if err != nil {
+				return nil, err
+			}
+			data[name] = float64(id)
+			continue
+		}
+
+		items, _ := value.([]interface{})
+		ids := make([]interface{}, len(items))
+		for i, item := range items {
+			id, err := graphQLEntryID(item)
+			if err != nil {
+				return nil, err
+			}
+			ids[i] = float64(id)
+		}
+		data[name] = ids
+	}
+	return data, nil
+}
+
+func graphQLEntryID(value interface{}) (uint, error) {
+	id, err := strconv.ParseUint(fmt.Sprint(value), 10, 32)
+	if err != nil || id == 0 {
+		return 0, newGraphQLError(http.StatusBadRequest, fmt.Sprintf("invalid entry ID %v", value))
+	}
+	return uint(id), nil
+}
+
+// graphQLFilterValues flattens a filters argument into filters[...] query parameters.
+// GraphQL names cannot start with $, so operators may be written without it: {price: {gt: 5}}.
+func graphQLFilterValues(values url.Values, key string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if strings.ContainsAny(name, "[]") {
+				return newGraphQLError(http.StatusBadRequest, fmt.Sprintf("invalid filter key %q", name))
+			}
+			if _, nested := child.(map[string]interface{}); !nested && key != "filters" && (filterOperators["$"+name] || plugins.IsFilterOperator("$"+name)) {
+				name = "$" + name
+			}
+			if err := graphQLFilterValues(values, key+"["+name+"]", child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if _, nested := item.(map[string]interface{}); nested {
+				return newGraphQLError(http.StatusBadRequest, "filter lists may contain values only")
+			}
+			values.Add(key, graphQLFilterValue(item))
+		}
+	default:
+		if key == "filters" {
+			return newGraphQLError(http.StatusBadRequest, "filters must be an object")
+		}
+		values.Add(key, graphQLFilterValue(v))
+	}
+	return nil
+}
+
+func graphQLFilterValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// graphQLSortOrder converts a sort argument ("price:desc,title") to an ORDER BY clause.
+// Without sort entries are ordered like in the REST API.
+func graphQLSortOrder(contentType *models.ContentType, sort string) (string, error) {
+	fields := splitList(sort)
+	if len(fields) == 0 {
+		if contentType.IsTree {
+			return "position ASC, id ASC", nil
+		}
+		return "created_at DESC", nil
+	}
+
+	order := make([]string, 0, len(fields)+1)
+	for _, item := range fields {
+		fieldName, direction := item, "ASC"
+		if i := strings.LastIndex(item, ":"); i >= 0 {
+			fieldName = item[:i]
+			switch strings.ToLower(item[i+1:]) {
+			case "asc":
+			case "desc":
+				direction = "DESC"
+			default:
+				return "", newGraphQLError(http.StatusBadRequest, fmt.Sprintf("invalid sort direction in %q", item))
+			}
+		}
+
+		if column, ok := entryColumns[fieldName]; ok {
+			order = append(order, column+" "+direction)
+			continue
+		}
+
+		fieldMap, ok := contentType.Schema[fieldName].(map[string]interface{})
+		if !ok || !database.IsValidJSONKey(fieldName) {
+			return "", newGraphQLError(http.StatusBadRequest, fmt.Sprintf("unknown field %s of %s", fieldName, contentType.UID))
+		}
+		fieldType, _ := fieldMap["type"].(string)
+		switch fieldType {
+		case "relation", "media", "component":
+			return "", newGraphQLError(http.StatusBadRequest, fmt.Sprintf("cannot sort by %s fields", fieldType))
+		}
+		if numberFieldTypes[fieldType] {
+			order = append(order, database.JSONNumber("data", fieldName)+" "+direction)
+		} else {
+			order = append(order, database.JSONText("data", fieldName)+" "+direction)
+		}
+	}
+
+	return strings.Join(append(order, "id ASC"), ", "), nil
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+)
+
+// graphQLSchemas holds one schema per access level, generated from the visible content
+// types the level can access, so that introspection does not reveal the fields of types
+// a client cannot read. Schemas are built on the first request of each level and dropped
+// by invalidateGraphQLSchema when a content type changes.
+var graphQLSchemas struct {
+	sync.Mutex
+	byLevel map[string]*graphql.Schema
+}
+
+// invalidateGraphQLSchema makes the next GraphQL requests rebuild the schemas
+func invalidateGraphQLSchema() {
+	graphQLSchemas.Lock()
+	graphQLSchemas.byLevel = nil
+	graphQLSchemas.Unlock()
+}
+
+// currentGraphQLSchema returns the schema of an access level, building it if needed
+func currentGraphQLSchema(level string) (*graphql.Schema, error) {
+	graphQLSchemas.Lock()
+	defer graphQLSchemas.Unlock()
+
+	if schema, ok := graphQLSchemas.byLevel[level]; ok {
+		return schema, nil
+	}
+
+	var visible []models.ContentType
+	if err := database.DB.Where("is_visible = ?", true).Order("uid ASC").Find(&visible).Error; err != nil {
+		return nil, err
+	}
+	contentTypes := make([]models.ContentType, 0, len(visible))
+	for _, contentType := range visible {
+		if middleware.AccessLevelAllows(level, contentType.AccessType) {
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+
+	schema, err := buildGraphQLSchema(contentTypes)
+	if err != nil {
+		return nil, err
+	}
+	if graphQLSchemas.byLevel == nil {
+		graphQLSchemas.byLevel = make(map[string]*graphql.Schema)
+	}
+	graphQLSchemas.byLevel[level] = schema
+	return schema, nil
+}
+
+var graphQLNamePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// graphQLReservedNames are type names used by the schema itself
+var graphQLReservedNames = map[string]bool{
+	"Query": true, "Mutation": true, "Subscription": true, "Pagination": true, "JSON": true,
+	"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true,
+}
+
+// graphQLEntryFields are entry attributes exposed on every type; schema fields with
+// the same name are left out
+var graphQLEntryFields = map[string]bool{
+	"id": true, "createdAt": true, "updatedAt": true, "publishedAt": true, "status": true,
+}
+
+// graphQLContentType is a content type with the GraphQL types generated for it
+type graphQLContentType struct {
+	contentType models.ContentType
+	name        string
+	object      *graphql.Object
+	collection  *graphql.Object
+	input       *graphql.InputObject
+}
+
+// graphQLTypeName converts a content type UID to a type name: blog-posts -> BlogPosts
+func graphQLTypeName(uid string) string {
+	parts := strings.FieldsFunc(uid, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// graphQLFieldType maps a schema field type to a GraphQL scalar
+func graphQLFieldType(fieldType string) *graphql.Scalar {
+	switch fieldType {
+	case "boolean":
+		return graphql.Boolean
+	case "integer":
+		return graphql.Int
+	case "number", "float", "decimal":
+		return graphql.Float
+	case "string", "text", "richtext", "email", "password", "uid", "date", "datetime", "time", "enumeration":
+		return graphql.String
+	}
+	return jsonScalar
+}
+
+// jsonScalar holds values without a fixed shape: components, media, json fields and filters
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value",
+	Serialize:   func(value interface{}) interface{} { return value },
+	ParseValue:  func(value interface{}) interface{} { return value },
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		return parseJSONLiteral(valueAST)
+	},
+})
+
+func parseJSONLiteral(valueAST ast.Value) interface{} {
+	switch value := valueAST.(type) {
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.IntValue:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *ast.FloatValue:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.ListValue:
+		items := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			items[i] = parseJSONLiteral(item)
+		}
+		return items
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = parseJSONLiteral(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+
+var paginationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Pagination",
+	Fields: graphql.Fields{
+		"page":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"pageSize": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"total":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// buildGraphQLSchema generates object, collection and input types for every content type
+// with the list, single and mutation fields operating on them. Content types whose UID
+// does not give a valid, unique type name are left out.
+func buildGraphQLSchema(contentTypes []models.ContentType) (*graphql.Schema, error) {
+	types := make(map[string]*graphQLContentType)
+	var ordered []*graphQLContentType
+	usedNames := make(map[string]bool)
+
+	for _, contentType := range contentTypes {
+		name := graphQLTypeName(contentType.UID)
+		if !graphQLNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			continue
+		}
+		derived := []string{name, name + "Collection", name + "Input", name + "ById"}
+		taken := false
+		for _, n := range derived {
+			if usedNames[n] || graphQLReservedNames[n] {
+				taken = true
+			}
+		}
+		if taken {
+			continue
+		}
+		for _, n := range derived {
+			usedNames[n] = true
+		}
+
+		t := &graphQLContentType{contentType: contentType, name: name}
+		types[contentType.UID] = t
+		ordered = append(ordered, t)
+	}
+
+	// Object types reference each other through relations, so fields are resolved lazily
+	for _, t := range ordered {
+		t := t
+		t.object = graphql.NewObject(graphql.ObjectConfig{
+			Name:        t.name,
+			Description: t.contentType.Description,
+			Fields:      graphql.FieldsThunk(func() graphql.Fields { return graphQLObjectFields(t, types) }),
+		})
+		t.collection = graphql.NewObject(graphql.ObjectConfig{
+			Name: t.name + "Collection",
+			Fields: graphql.Fields{
+				"data": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t.object)))},
+				"meta": &graphql.Field{Type: graphql.NewNonNull(paginationType)},
+			},
+		})
+		t.input = graphql.NewInputObject(graphql.InputObjectConfig{
+			Name:   t.name + "Input",
+			Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap { return graphQLInputFields(t, types) }),
+		})
+	}
+
+	queryFields := graphql.Fields{}
+	mutationFields := graphql.Fields{}
+	for _, t := range ordered {
+		addGraphQLQueryFields(queryFields, t)
+		addGraphQLMutationFields(mutationFields, t)
+	}
+
+	// A schema needs at least one query field
+	if len(queryFields) == 0 {
+		queryFields["contentTypes"] = &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) { return []string{}, nil },
+		}
+	}
+
+	config := graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: queryFields}),
+	}
+	if len(mutationFields) > 0 {
+		config.Mutation = graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: mutationFields})
+	}
+
+	schema, err := graphql.NewSchema(config)
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// graphQLSchemaFields returns the schema fields of a content type usable as GraphQL fields
+func graphQLSchemaFields(contentType *models.ContentType) []models.ContentField {
+	var fields []models.ContentField
+	for name, definition := range contentType.Schema {
+		fieldMap, ok := definition.(map[string]interface{})
+		if !ok || !graphQLNamePattern.MatchString(name) || strings.HasPrefix(name, "__") || graphQLEntryFields[name] {
+			continue
+		}
+		field := models.ContentField{Name: name}
+		field.Type, _ = fieldMap["type"].(string)
+		if field.Type == "relation" {
+			field, _ = relationField(contentType.Schema, name)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func graphQLObjectFields(t *graphQLContentType, types map[string]*graphQLContentType) graphql.Fields {
+	fields := graphql.Fields{
+		"id": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*models.ContentEntry).ID, nil },
+		},
+		"status": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*models.ContentEntry).Status, nil },
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.ContentEntry).CreatedAt.Format(time.RFC3339), nil
+			},
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.ContentEntry).UpdatedAt.Format(time.RFC3339), nil
+			},
+		},
+		"publishedAt": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if publishedAt := p.Source.(*models.ContentEntry).PublishedAt; publishedAt != nil {
+					return publishedAt.Format(time.RFC3339), nil
+				}
+				return nil, nil
+			},
+		},
+	}
+
+	for _, field := range graphQLSchemaFields(&t.contentType) {
+		field := field
+
+		if field.Type != "relation" {
+			fields[field.Name] = &graphql.Field{
+				Type:        graphQLFieldType(field.Type),
+				Description: field.Description,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					value := p.Source.(*models.ContentEntry).Data[field.Name]
+					if fieldType, fieldMap, ok := pluginFieldType(t.contentType.Schema, field.Name); ok && fieldType.Serialize != nil && value != nil {
+						return fieldType.Serialize(value, fieldMap), nil
+					}
+					return value, nil
+				},
+			}
+			continue
+		}
+
+		// Relations to content types missing from the schema are left out
+		target, ok := types[field.TargetContentType]
+		if !ok {
+			continue
+		}
+		var fieldType graphql.Output = target.object
+		if isToManyRelation(field.RelationType) {
+			fieldType = graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(target.object)))
+		}
+		fields[field.Name] = &graphql.Field{
+			Type:        fieldType,
+			Description: field.Description,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return resolveGraphQLRelation(p, t.contentType.UID, field)
+			},
+		}
+	}
+
+	return fields
+}
+
+func graphQLInputFields(t *graphQLContentType, types map[string]*graphQLContentType) graphql.InputObjectConfigFieldMap {
+	fields := graphql.InputObjectConfigFieldMap{}
+	for _, field := range graphQLSchemaFields(&t.contentType) {
+		var fieldType graphql.Input = graphQLFieldType(field.Type)
+		if field.Type == "relation" {
+			// Inverse sides are written through the owning side
+			if _, ok := types[field.TargetContentType]; !ok || field.MappedBy != "" {
+				continue
+			}
+			fieldType = graphql.ID
+			if isToManyRelation(field.RelationType) {
+				fieldType = graphql.NewList(graphql.NewNonNull(graphql.ID))
+			}
+		}
+		fields[field.Name] = &graphql.InputObjectFieldConfig{Type: fieldType, Description: field.Description}
+	}
+
+	// An input object needs at least one field
+	if len(fields) == 0 {
+		fields["_"] = &graphql.InputObjectFieldConfig{Type: graphql.Boolean, Description: "Unused"}
+	}
+	return fields
+}
+
+func addGraphQLQueryFields(fields graphql.Fields, t *graphQLContentType) {
+	fields[lowerFirst(t.name)] = &graphql.Field{
+		Type:        graphql.NewNonNull(t.collection),
+		Description: "Published " + t.contentType.DisplayName + " entries",
+		Args: graphql.FieldConfigArgument{
+			"filters":  &graphql.ArgumentConfig{Type: jsonScalar, Description: `Filters as in the REST API, e.g. {"author": {"name": {"$eq": "Ann"}}}`},
+			"sort":     &graphql.ArgumentConfig{Type: graphql.String, Description: "Comma-separated fields with optional :asc or :desc"},
+			"search":   &graphql.ArgumentConfig{Type: graphql.String},
+			"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+			"pageSize": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultGraphQLPageSize},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return resolveGraphQLEntries(p, &t.contentType)
+		},
+	}
+
+	fields[lowerFirst(t.name)+"ById"] = &graphql.Field{
+		Type:        t.object,
+		Description: "A published " + t.contentType.DisplayName + " entry",
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return resolveGraphQLEntry(p, &t.contentType)
+		},
+	}
+}
+
+func addGraphQLMutationFields(fields graphql.Fields, t *graphQLContentType) {
+	fields["create"+t.name] = &graphql.Field{
+		Type: graphql.NewNonNull(t.object),
+		Args: graphql.FieldConfigArgument{
+			"data":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(t.input)},
+			"status":   &graphql.ArgumentConfig{Type: graphql.String},
+			"parentId": &graphql.ArgumentConfig{Type: graphql.ID},
+			"position": &graphql.ArgumentConfig{Type: graphql.Int},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return resolveGraphQLCreate(p, &t.contentType)
+		},
+	}
+
+	fields["update"+t.name] = &graphql.Field{
+		Type: graphql.NewNonNull(t.object),
+		Args: graphql.FieldConfigArgument{
+			"id":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"data":   &graphql.ArgumentConfig{Type: t.input},
+			"status": &graphql.ArgumentConfig{Type: graphql.String},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return resolveGraphQLUpdate(p, &t.contentType)
+		},
+	}
+
+	fields["delete"+t.name] = &graphql.Field{
+		Type:        graphql.NewNonNull(jsonScalar),
+		Description: "Deletes an entry, returns the deleted entries (including cascades)",
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return resolveGraphQLDelete(p, &t.contentType)
+		},
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,6 +14,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/xivercms/xivercms/database"
 	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
 )
 
 const uploadDir = "./uploads"
@@ -102,11 +105,18 @@ func UploadMedia(c *gin.Context) {
 		mediaFile.Caption = caption
 	}
 
-	if err := database.DB.Create(&mediaFile).Error; err != nil {
+	// The record and its webhook deliveries are written together
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&mediaFile).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.MediaUpload, "", map[string]interface{}{"media": mediaFile})
+	}); err != nil {
 		os.Remove(filePath) // Clean up file if DB save fails
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save media file record"})
 		return
 	}
+	webhooks.Notify()
 
 	c.JSON(http.StatusCreated, mediaFile)
 }
@@ -211,16 +221,21 @@ func DeleteMediaFile(c *gin.Context) {
 		return
 	}
 
-	// Delete file from filesystem
-	if err := os.Remove(mediaFile.Path); err != nil && !os.IsNotExist(err) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+	// Delete the record together with its webhook deliveries, then the file. The file
+	// goes last so that a failed delete leaves the record usable.
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&mediaFile).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.MediaDelete, "", map[string]interface{}{"media": mediaFile})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	webhooks.Notify()
 
-	// Delete record from database
-	if err := database.DB.Delete(&mediaFile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if err := os.Remove(mediaFile.Path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to delete media file %s: %v", mediaFile.Path, err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Media file deleted successfully"})
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/notifications"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+// pendingNotificationsKey holds the notifications of a request until its changes are committed
+const pendingNotificationsKey = "pendingNotifications"
+
+// GetNotifications lists the notifications of the current user, newest first
+func GetNotifications(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var list []models.Notification
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	offset := (page - 1) * pageSize
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userId)
+
+	// Filter by read state and type
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if notificationType := c.Query("type"); notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	if err := query.Offset(offset).Limit(pageSize).
+		Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": list,
+		"meta": gin.H{
+			"pagination": gin.H{
+				"page":     page,
+				"pageSize": pageSize,
+				"total":    total,
+			},
+			"unread": unreadNotificationCount(userId.(uint)),
+		},
+	})
+}
+
+// GetUnreadNotificationCount returns the number of unread notifications, e.g. for a badge
+func GetUnreadNotificationCount(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": unreadNotificationCount(userId.(uint))})
+}
+
+// MarkNotificationRead marks one notification of the current user as read
+func MarkNotificationRead(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var notification models.Notification
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userId).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := database.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks the unread notifications of the current user as read,
+// optionally only those of one type
+func MarkAllNotificationsRead(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userId)
+	if notificationType := c.Query("type"); notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	result := query.Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+func unreadNotificationCount(userID uint) int64 {
+	var count int64
+	database.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count)
+	return count
+}
+
+// queueNotifications stores notifications inside the transaction of the change that caused
+// them, leaving out the user who made the change. deliverNotifications sends them through
+// the delivery channels after the commit.
+func queueNotifications(tx *gorm.DB, c writeContext, list ...models.Notification) error {
+	var actorID *uint
+	if userId, exists := c.Get("userId"); exists {
+		id := userId.(uint)
+		actorID = &id
+	}
+
+	recipients := make([]models.Notification, 0, len(list))
+	for _, notification := range list {
+		if actorID != nil && notification.UserID == *actorID {
+			continue
+		}
+		notification.ActorID = actorID
+		recipients = append(recipients, notification)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	if err := tx.Create(&recipients).Error; err != nil {
+		return err
+	}
+
+	pending, _ := c.Get(pendingNotificationsKey)
+	queued, _ := pending.([]models.Notification)
+	c.Set(pendingNotificationsKey, append(queued, recipients...))
+	return nil
+}
+
+// deliverNotifications sends the notifications queued by a committed request
+func deliverNotifications(c writeContext) {
+	pending, _ := c.Get(pendingNotificationsKey)
+	if queued, _ := pending.([]models.Notification); len(queued) > 0 {
+		c.Set(pendingNotificationsKey, nil)
+		sendNotifications(queued)
+	}
+}
+
+// sendNotifications hands stored notifications to the delivery channels
+func sendNotifications(list []models.Notification) {
+	if len(list) == 0 || len(notifications.Channels()) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(list))
+	for _, notification := range list {
+		ids = append(ids, notification.UserID)
+	}
+	var users []models.User
+	database.DB.Where("id IN ? AND is_active = ?", ids, true).Find(&users)
+	byID := make(map[uint]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+
+	messages := make([]notifications.Message, 0, len(list))
+	for _, notification := range list {
+		user, ok := byID[notification.UserID]
+		if !ok {
+			continue
+		}
+		messages = append(messages, notifications.Message{
+			NotificationID: notification.ID,
+			Type:           notification.Type,
+			Title:          notification.Title,
+			Body:           notification.Body,
+			Link:           notification.Link,
+			Recipient:      notifications.Recipient{UserID: user.ID, Email: user.Email, Username: user.Username},
+			CreatedAt:      notification.CreatedAt,
+		})
+	}
+	notifications.Deliver(messages...)
+}
+
+// entryLink is the admin UI path of an entry
+func entryLink(contentTypeUID string, entryID uint) string {
+	return fmt.Sprintf("/content-types/%s/entries/%d", contentTypeUID, entryID)
+}
+
+// entryLabel names an entry in notifications: its title or name, otherwise its ID
+func entryLabel(contentType *models.ContentType, entry *models.ContentEntry) string {
+	for _, field := range []string{"title", "name"} {
+		if value, ok := entry.Data[field].(string); ok && value != "" {
+			return fmt.Sprintf("%s \"%s\"", contentType.DisplayName, value)
+		}
+	}
+	return fmt.Sprintf("%s #%d", contentType.DisplayName, entry.ID)
+}
+
+// queueEntryStatusNotifications tells the author of an entry that someone else published,
+// unpublished or deleted it
+func queueEntryStatusNotifications(tx *gorm.DB, c writeContext, contentType *models.ContentType, entry *models.ContentEntry, events []string) error {
+	if entry.CreatedByID == nil {
+		return nil
+	}
+
+	label := entryLabel(contentType, entry)
+	data := models.JSONB{"contentType": contentType.UID, "entryId": entry.ID, "status": entry.Status}
+
+	var list []models.Notification
+	for _, event := range events {
+		notification := models.Notification{
+			UserID: *entry.CreatedByID,
+			Type:   models.NotificationEntryStatus,
+			Link:   entryLink(contentType.UID, entry.ID),
+			Data:   data,
+		}
+		switch event {
+		case webhooks.EntryPublish:
+			notification.Title = label + " was published"
+		case webhooks.EntryUnpublish:
+			notification.Title = label + " was unpublished"
+		case webhooks.EntryDelete:
+			notification.Type = models.NotificationEntryDelete
+			notification.Title = label + " was deleted"
+			notification.Link = ""
+		default:
+			continue
+		}
+		list = append(list, notification)
+	}
+	return queueNotifications(tx, c, list...)
+}
+
+// queueCommentNotifications tells mentioned users about a comment, and the other
+// participants of a thread about a reply
+func queueCommentNotifications(tx *gorm.DB, c *gin.Context, comment *models.Comment, mentioned []models.User) error {
+	list := mentionNotifications(tx, comment, mentioned)
+
+	if comment.ParentID != nil {
+		notified := make(map[uint]bool, len(mentioned))
+		for _, user := range mentioned {
+			notified[user.ID] = true
+		}
+
+		var participants []uint
+		tx.Model(&models.Comment{}).
+			Where("(id = ? OR parent_id = ?) AND removed = ?", *comment.ParentID, *comment.ParentID, false).
+			Distinct().Pluck("author_id", &participants)
+		for _, userID := range participants {
+			if notified[userID] {
+				continue
+			}
+			notified[userID] = true
+			notification := commentNotification(comment, userID, models.NotificationReply)
+			notification.Title = commentAuthorName(tx, comment) + " replied to a comment thread"
+			list = append(list, notification)
+		}
+	}
+
+	return queueNotifications(tx, c, list...)
+}
+
+// mentionNotifications tells users that a comment mentions them
+func mentionNotifications(db *gorm.DB, comment *models.Comment, mentioned []models.User) []models.Notification {
+	if len(mentioned) == 0 {
+		return nil
+	}
+
+	title := commentAuthorName(db, comment) + " mentioned you in a comment"
+	list := make([]models.Notification, 0, len(mentioned))
+	for _, user := range mentioned {
+		notification := commentNotification(comment, user.ID, models.NotificationMention)
+		notification.Title = title
+		list = append(list, notification)
+	}
+	return list
+}
+
+func commentNotification(comment *models.Comment, userID uint, notificationType string) models.Notification {
+	data := models.JSONB{"contentType": comment.ContentTypeUID, "entryId": comment.EntryID, "commentId": comment.ID}
+	if comment.Field != "" {
+		data["field"] = comment.Field
+	}
+	return models.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Body:   comment.Body,
+		Link:   entryLink(comment.ContentTypeUID, comment.EntryID),
+		Data:   data,
+	}
+}
+
+func commentAuthorName(db *gorm.DB, comment *models.Comment) string {
+	var author models.User
+	if err := db.First(&author, comment.AuthorID).Error; err != nil {
+		return "Someone"
+	}
+	return author.Username
+}
+
+// queueSubmissionNotifications tells the users who review submissions about a new one:
+// super admins and users with the read permission on submissions. Recipients are selected
+// in one query, the endpoint is public and the number of users may be large.
+func queueSubmissionNotifications(tx *gorm.DB, c *gin.Context, contentType *models.ContentType, entry *models.ContentEntry) error {
+	var userIDs []uint
+	if err := tx.Model(&models.User{}).
+		Joins("LEFT JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("LEFT JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Joins("LEFT JOIN role_permissions ON role_permissions.role_id = roles.id").
+		Joins("LEFT JOIN permissions ON permissions.id = role_permissions.permission_id AND permissions.deleted_at IS NULL").
+		Where("users.is_active = ?", true).
+		Where(database.DB.Where("users.is_super_admin = ?", true).
+			Or("permissions.action IN ? AND (permissions.subject IN ? OR permissions.subject LIKE ?)",
+				[]string{"read", "all"}, []string{"submission", "all"}, "submission:%")).
+		Distinct().Pluck("users.id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	list := make([]models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		list = append(list, models.Notification{
+			UserID: userID,
+			Type:   models.NotificationSubmission,
+			Title:  "New submission: " + entryLabel(contentType, entry),
+			Link:   entryLink(contentType.UID, entry.ID),
+			Data:   models.JSONB{"contentType": contentType.UID, "entryId": entry.ID},
+		})
+	}
+	return queueNotifications(tx, c, list...)
+}
+
+// notifyReleaseFailed tells the creator of a scheduled release that it failed
+func notifyReleaseFailed(release *models.Release, message string) {
+	if release.CreatedByID == nil {
+		return
+	}
+
+	notification := models.Notification{
+		UserID: *release.CreatedByID,
+		Type:   models.NotificationReleaseFailed,
+		Title:  fmt.Sprintf("Scheduled release \"%s\" failed", release.Name),
+		Body:   message,
+		Data:   models.JSONB{"releaseId": release.ID},
+	}
+	if err := database.DB.Create(&notification).Error; err != nil {
+		return
+	}
+	sendNotifications([]models.Notification{notification})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/plugins"
+)
+
+// pluginRoutePrefix is where the admin routes of plugins are mounted
+const pluginRoutePrefix = "/api/plugins"
+
+// GetPlugins lists the compiled-in plugins with what the enabled ones registered
+func GetPlugins(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": plugins.List(pluginRoutePrefix)})
+}
+
+// pluginFieldType returns the plugin field type of a schema field
+func pluginFieldType(schema models.JSONB, fieldName string) (*plugins.FieldType, map[string]interface{}, bool) {
+	fieldMap, ok := schema[fieldName].(map[string]interface{})
+	if !ok {
+		return nil, nil, false
+	}
+	typeName, _ := fieldMap["type"].(string)
+	fieldType, ok := plugins.LookupFieldType(typeName)
+	return fieldType, fieldMap, ok
+}
+
+// validatePluginFields checks the values of plugin-typed fields in data, returning a message
+// per invalid field or nil
+func validatePluginFields(schema models.JSONB, data map[string]interface{}) map[string]string {
+	var fieldErrors map[string]string
+	for name, value := range data {
+		fieldType, fieldMap, ok := pluginFieldType(schema, name)
+		if !ok || fieldType.Validate == nil || value == nil {
+			continue
+		}
+		if err := fieldType.Validate(value, fieldMap); err != nil {
+			if fieldErrors == nil {
+				fieldErrors = make(map[string]string)
+			}
+			fieldErrors[name] = err.Error()
+		}
+	}
+	return fieldErrors
+}
+
+// serializePluginFields returns a copy of data with plugin-typed fields converted for
+// API responses
+func serializePluginFields(schema models.JSONB, data models.JSONB) models.JSONB {
+	var serialized models.JSONB
+	for name, value := range data {
+		fieldType, fieldMap, ok := pluginFieldType(schema, name)
+		if !ok || fieldType.Serialize == nil || value == nil {
+			continue
+		}
+		if serialized == nil {
+			serialized = make(models.JSONB, len(data))
+			for k, v := range data {
+				serialized[k] = v
+			}
+		}
+		serialized[name] = fieldType.Serialize(value, fieldMap)
+	}
+	if serialized == nil {
+		return data
+	}
+	return serialized
+}
+
+// pluginFilterCondition builds a condition with a filter operator of a plugin field type.
+// ok is false if the field has no such operator.
+func pluginFilterCondition(schema models.JSONB, fieldName, op string, values []string, text, number string) (string, []interface{}, bool, error) {
+	fieldType, fieldMap, ok := pluginFieldType(schema, fieldName)
+	if !ok {
+		return "", nil, false, nil
+	}
+	operator, ok := fieldType.Operators[op]
+	if !ok {
+		return "", nil, false, nil
+	}
+
+	condition, args, err := operator(plugins.FilterField{Name: fieldName, Schema: fieldMap, Text: text, Number: number}, values)
+	if err != nil {
+		return "", nil, true, badFilter("%s", err.Error())
+	}
+	if condition == "" {
+		return "", nil, true, fmt.Errorf("filter operator %s of %s returned no condition", op, fieldType.Name)
+	}
+	return "(" + condition + ")", args, true, nil
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/auth"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPreviewTokenLifetime = 7 * 24 * time.Hour
+	maxPreviewTokenLifetime     = 30 * 24 * time.Hour
+)
+
+func GetPreviewTokens(c *gin.Context) {
+	var tokens []models.PreviewToken
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	offset := (page - 1) * pageSize
+
+	query := database.DB.Model(&models.PreviewToken{})
+
+	// Filter by content type and entry
+	if uid := c.Query("contentType"); uid != "" {
+		query = query.Where("content_type_uid = ?", uid)
+	}
+	if entryID := c.Query("entryId"); entryID != "" {
+		query = query.Where("entry_id = ?", entryID)
+	}
+
+	// Only tokens that can still be used
+	if c.Query("active") == "true" {
+		query = query.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	var total int64
+	query.Count(&total)
+
+	if err := query.Offset(offset).Limit(pageSize).
+		Preload("CreatedBy").
+		Order("created_at DESC").
+		Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tokens,
+		"meta": gin.H{
+			"pagination": gin.H{
+				"page":     page,
+				"pageSize": pageSize,
+				"total":    total,
+			},
+		},
+	})
+}
+
+func GetPreviewToken(c *gin.Context) {
+	var token models.PreviewToken
+	if err := database.DB.Preload("CreatedBy").First(&token, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+type CreatePreviewTokenRequest struct {
+	Name        string     `json:"name"`
+	ContentType string     `json:"contentType" binding:"required"`
+	EntryID     *uint      `json:"entryId"`   // Omit to preview every entry of the content type
+	ExpiresAt   *time.Time `json:"expiresAt"` // 7 days by default, at most 30 days
+}
+
+// CreatePreviewToken issues a signed preview token. The token is only part of this response.
+func CreatePreviewToken(c *gin.Context) {
+	var req CreatePreviewTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", req.ContentType).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content type not found"})
+		return
+	}
+	if req.EntryID != nil {
+		var count int64
+		database.DB.Model(&models.ContentEntry{}).Where("id = ? AND content_type_id = ?", *req.EntryID, contentType.ID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Entry not found"})
+			return
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(defaultPreviewTokenLifetime)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) || expiresAt.Sub(now) > maxPreviewTokenLifetime {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future and at most 30 days away"})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	userID := userId.(uint)
+
+	record := models.PreviewToken{
+		Name:           req.Name,
+		ContentTypeUID: contentType.UID,
+		EntryID:        req.EntryID,
+		ExpiresAt:      expiresAt,
+		CreatedByID:    &userID,
+	}
+
+	var signed string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		var entryID uint
+		if record.EntryID != nil {
+			entryID = *record.EntryID
+		}
+		var err error
+		signed, err = auth.GeneratePreviewToken(record.ID, record.ContentTypeUID, entryID, record.ExpiresAt)
+		if err != nil {
+			return err
+		}
+
+		return createAuditLog(tx, c, "create", "preview-token", &record.ID, "Created preview token", map[string]interface{}{
+			"contentType": record.ContentTypeUID,
+			"entryId":     record.EntryID,
+			"expiresAt":   record.ExpiresAt,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record, "token": signed})
+}
+
+// RevokePreviewToken makes a preview token unusable before it expires
+func RevokePreviewToken(c *gin.Context) {
+	var token models.PreviewToken
+	if err := database.DB.First(&token, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview token not found"})
+		return
+	}
+
+	if token.RevokedAt == nil {
+		userId, _ := c.Get("userId")
+		userID := userId.(uint)
+		now := time.Now()
+		token.RevokedAt = &now
+		token.RevokedByID = &userID
+
+		if err := database.DB.Save(&token).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		CreateAuditLog(c, "revoke", "preview-token", &token.ID, "Revoked preview token", map[string]interface{}{
+			"contentType": token.ContentTypeUID,
+			"entryId":     token.EntryID,
+		})
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// requestPreviewToken returns the preview token sent with a request: the X-Preview-Token
+// header or the previewToken query parameter, for links shared with reviewers
+func requestPreviewToken(c *gin.Context) string {
+	if token := c.GetHeader("X-Preview-Token"); token != "" {
+		return token
+	}
+	return c.Query("previewToken")
+}
+
+// resolvePreviewToken checks the preview token of a request for a content type,
+// responding 401 if it is invalid, expired, revoked or scoped to another content type
+func resolvePreviewToken(c *gin.Context, contentTypeUID string) (*models.PreviewToken, bool) {
+	claims, err := auth.ValidatePreviewToken(requestPreviewToken(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired preview token"})
+		return nil, false
+	}
+
+	var token models.PreviewToken
+	if err := database.DB.First(&token, claims.ID).Error; err != nil || token.RevokedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Preview token has been revoked"})
+		return nil, false
+	}
+	if !token.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired preview token"})
+		return nil, false
+	}
+	if token.ContentTypeUID != contentTypeUID || claims.ContentType != contentTypeUID {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Preview token is not valid for this content type"})
+		return nil, false
+	}
+	return &token, true
+}
+
+// previewAllows reports whether a preview token covers an entry
+func previewAllows(token *models.PreviewToken, entry *models.ContentEntry) bool {
+	return token.EntryID == nil || *token.EntryID == entry.ID
+}
+
+// recordPreviewUse counts the use of a preview token and writes it to the audit log
+func recordPreviewUse(c *gin.Context, token *models.PreviewToken, entry *models.ContentEntry) {
+	now := time.Now()
+	database.DB.Model(token).Updates(map[string]interface{}{
+		"last_used_at": now,
+		"use_count":    gorm.Expr("use_count + 1"),
+	})
+
+	CreateAuditLog(c, "preview", "content-entry", &entry.ID, "Previewed content entry", map[string]interface{}{
+		"contentType":    token.ContentTypeUID,
+		"status":         entry.Status,
+		"previewTokenId": token.ID,
+	})
+}
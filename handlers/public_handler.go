@@ -1,15 +1,30 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
 	"github.com/xivercms/xivercms/database"
 	"github.com/xivercms/xivercms/middleware"
 	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
 )
 
+// reservedRoutes are /api/{segment} prefixes used by the CMS itself, never content type UIDs
+var reservedRoutes = []string{"auth", "roles", "users", "permissions", "api-tokens", "upload", "media-files", "content-types", "admin", "audit-logs", "redirects", "aggregate", "plugins", "stream", "feeds", "webhooks", "releases", "preview-tokens", "notifications"}
+
+func isReservedRoute(uid string) bool {
+	for _, reserved := range reservedRoutes {
+		if uid == reserved {
+			return true
+		}
+	}
+	return false
+}
+
 // safeUserResponse returns a safe representation of user data for public APIs
 // For security, only returns minimal non-sensitive information
 // Email, firstName, lastName are intentionally excluded as they are personal data
@@ -25,6 +40,28 @@ func safeUserResponse(user *models.User) map[string]interface{} {
 	}
 }
 
+// publicEntryMap formats an entry for public APIs with safe user data
+func publicEntryMap(entry *models.ContentEntry) map[string]interface{} {
+	entryMap := map[string]interface{}{
+		"id":            entry.ID,
+		"createdAt":     entry.CreatedAt,
+		"updatedAt":     entry.UpdatedAt,
+		"publishedAt":   entry.PublishedAt,
+		"contentTypeId": entry.ContentTypeID,
+		"data":          entry.Data,
+		"status":        entry.Status,
+		"parentId":      entry.ParentID,
+		"position":      entry.Position,
+	}
+	if entry.CreatedBy != nil {
+		entryMap["createdBy"] = safeUserResponse(entry.CreatedBy)
+	}
+	if entry.UpdatedBy != nil {
+		entryMap["updatedBy"] = safeUserResponse(entry.UpdatedBy)
+	}
+	return entryMap
+}
+
 // PublicGetContentTypes - get content types (public or admin based on auth)
 // If authenticated as admin, returns all content types including non-visible ones
 // Otherwise, returns only visible and accessible content types
@@ -190,12 +227,9 @@ func PublicGetContentEntries(c *gin.Context) {
 	contentTypeUID := c.Param("uid")
 
 	// Skip if this is a reserved route (shouldn't happen if routes are ordered correctly)
-	reservedRoutes := []string{"auth", "roles", "users", "permissions", "api-tokens", "upload", "media-files", "content-types", "admin", "audit-logs"}
-	for _, reserved := range reservedRoutes {
-		if contentTypeUID == reserved {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
-			return
-		}
+	if isReservedRoute(contentTypeUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
 	}
 
 	var contentType models.ContentType
@@ -210,50 +244,93 @@ func PublicGetContentEntries(c *gin.Context) {
 		return
 	}
 
+	cache.AddTags(c, cache.ContentTypeTag(contentTypeUID), cache.ListTag(contentTypeUID))
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
 	offset := (page - 1) * pageSize
 
 	query := database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ? AND status = ?", contentType.ID, "published")
 
+	// Restricted entries the client may not read are left out, also from the total
+	viewer := currentEntryViewer(c)
+	query = viewer.scope(query)
+
+	// Nested tree of published entries (tree-enabled content types).
+	// Unpublished and restricted nodes are left out together with their subtree.
+	if c.Query("tree") == "true" && contentType.IsTree {
+		var entries []models.ContentEntry
+		if err := query.Preload("CreatedBy").Order("position ASC, id ASC").Find(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		validator := newCacheValidator(c, &contentType)
+		validator.addCollection(&contentType)
+		for i := range entries {
+			validator.addEntry(&entries[i])
+		}
+		if validator.notModified(c, &contentType) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": buildEntryTree(entries, publicEntryMap),
+			"meta": gin.H{
+				"total": len(entries),
+			},
+		})
+		return
+	}
+
 	// Search (simplified for SQLite compatibility)
 	if search := c.Query("search"); search != "" {
 		// Simple LIKE search on JSON text - works with both SQLite and PostgreSQL
 		query = query.Where("data LIKE ?", "%"+search+"%")
 	}
 
+	// Filter by parent (tree-enabled content types)
+	query = applyParentFilter(c, query)
+
+	// Filters on entry fields and on attributes of related entries
+	query, err := applyEntryFilters(c, query, &contentType, true)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	order := "created_at DESC"
+	if contentType.IsTree {
+		order = "position ASC, id ASC"
+	}
+
 	var total int64
 	query.Count(&total)
 
 	var entries []models.ContentEntry
 	if err := query.Offset(offset).Limit(pageSize).
 		Preload("CreatedBy").
-		Order("created_at DESC").
+		Order(order).
 		Find(&entries).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	validator := newCacheValidator(c, &contentType)
+	validator.addCollection(&contentType)
+	validator.addValue(total)
+	for i := range entries {
+		validator.addEntry(&entries[i])
+	}
+	if validator.notModified(c, &contentType) {
+		return
+	}
+
 	// Format entries with safe user data
 	formattedEntries := make([]map[string]interface{}, len(entries))
-	for i, entry := range entries {
-		entryMap := map[string]interface{}{
-			"id":            entry.ID,
-			"createdAt":     entry.CreatedAt,
-			"updatedAt":     entry.UpdatedAt,
-			"publishedAt":   entry.PublishedAt,
-			"contentTypeId": entry.ContentTypeID,
-			"data":          entry.Data,
-			"status":        entry.Status,
-		}
-		// Add safe user data
-		if entry.CreatedBy != nil {
-			entryMap["createdBy"] = safeUserResponse(entry.CreatedBy)
-		}
-		if entry.UpdatedBy != nil {
-			entryMap["updatedBy"] = safeUserResponse(entry.UpdatedBy)
-		}
-		formattedEntries[i] = entryMap
+	for i := range entries {
+		entries[i].Data = serializePluginFields(contentType.Schema, entries[i].Data)
+		formattedEntries[i] = publicEntryMap(&entries[i])
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -269,18 +346,15 @@ func PublicGetContentEntries(c *gin.Context) {
 }
 
 // PublicGetContentEntry - get public content entry
-// Handles /api/{uid}/{id} - simplified URL
+// Handles /api/{uid}/{id} - simplified URL, {id} may also be a slug
 func PublicGetContentEntry(c *gin.Context) {
 	contentTypeUID := c.Param("uid")
 	entryID := c.Param("id")
 
 	// Skip if this is a reserved route
-	reservedRoutes := []string{"auth", "roles", "users", "permissions", "api-tokens", "upload", "media-files", "content-types", "admin", "audit-logs"}
-	for _, reserved := range reservedRoutes {
-		if contentTypeUID == reserved {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
-			return
-		}
+	if isReservedRoute(contentTypeUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
 	}
 
 	var contentType models.ContentType
@@ -289,84 +363,103 @@ func PublicGetContentEntry(c *gin.Context) {
 		return
 	}
 
+	// A preview token grants access to unpublished entries of its content type
+	var preview *models.PreviewToken
+	if requestPreviewToken(c) != "" {
+		token, ok := resolvePreviewToken(c, contentTypeUID)
+		if !ok {
+			return
+		}
+		preview = token
+	}
+
 	// Check access
-	if !middleware.CheckContentTypeAccess(contentTypeUID, c) {
+	if preview == nil && !middleware.CheckContentTypeAccess(contentTypeUID, c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
+	// A preview token also grants access to restricted entries
+	viewer := currentEntryViewer(c)
+	publishedQuery := func() *gorm.DB {
+		query := database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ?", contentType.ID)
+		if preview == nil {
+			query = viewer.scope(query.Where("status = ?", "published"))
+		}
+		return query.Preload("CreatedBy").Preload("UpdatedBy")
+	}
+
+	// The identifier is a numeric ID or, for content types with uid fields, a slug
 	var entry models.ContentEntry
-	if err := database.DB.Where("id = ? AND content_type_id = ? AND status = ?", entryID, contentType.ID, "published").
-		Preload("CreatedBy").
-		Preload("UpdatedBy").
-		First(&entry).Error; err != nil {
+	err := gorm.ErrRecordNotFound
+	if _, parseErr := strconv.ParseUint(entryID, 10, 32); parseErr == nil {
+		err = publishedQuery().Where("id = ?", entryID).First(&entry).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if slugQuery := whereSlug(publishedQuery(), contentType.Schema, entryID); slugQuery != nil {
+			err = slugQuery.First(&entry).Error
+		}
+	}
+	if err != nil || (preview != nil && !previewAllows(preview, &entry)) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
 		return
 	}
+	if preview != nil {
+		recordPreviewUse(c, preview, &entry)
+	}
 
-	// Load relations if requested
-	if c.Query("populate") == "true" {
-		var relations []models.ContentRelation
-		database.DB.Where("source_content_type_uid = ? AND source_entry_id = ?", contentTypeUID, entryID).Find(&relations)
-
-		// Populate related entries
-		populatedData := make(map[string]interface{})
-		for k, v := range entry.Data {
-			populatedData[k] = v
-		}
+	validator := newCacheValidator(c, &contentType)
+	validator.addEntry(&entry)
+	cache.AddTags(c, cache.ContentTypeTag(contentTypeUID), cache.EntryTag(contentTypeUID, entry.ID))
 
-		// Add relations to data
-		for _, relation := range relations {
-			var relatedEntry models.ContentEntry
-			var relatedContentType models.ContentType
-			database.DB.Where("uid = ?", relation.TargetContentTypeUID).First(&relatedContentType)
-			if err := database.DB.Where("content_type_id = ? AND id = ? AND status = ?", relatedContentType.ID, relation.TargetEntryID, "published").
-				Preload("CreatedBy").
-				First(&relatedEntry).Error; err == nil {
-				fieldName := relation.SourceFieldName
-				// Format related entry with safe user data
-				relatedEntryMap := map[string]interface{}{
-					"id":            relatedEntry.ID,
-					"createdAt":     relatedEntry.CreatedAt,
-					"updatedAt":     relatedEntry.UpdatedAt,
-					"publishedAt":   relatedEntry.PublishedAt,
-					"contentTypeId": relatedEntry.ContentTypeID,
-					"data":          relatedEntry.Data,
-					"status":        relatedEntry.Status,
-				}
-				if relatedEntry.CreatedBy != nil {
-					relatedEntryMap["createdBy"] = safeUserResponse(relatedEntry.CreatedBy)
-				}
+	entry.Data = serializePluginFields(contentType.Schema, entry.Data)
 
-				if relation.RelationType == "oneToMany" || relation.RelationType == "manyToMany" {
-					if populatedData[fieldName] == nil {
-						populatedData[fieldName] = []interface{}{}
-					}
-					arr := populatedData[fieldName].([]interface{})
-					populatedData[fieldName] = append(arr, relatedEntryMap)
-				} else {
-					populatedData[fieldName] = relatedEntryMap
-				}
+	// Load relations if requested (including inverse sides of bidirectional relations)
+	if c.Query("populate") == "true" {
+		loadPublished := relatedEntryLoader(viewer)
+		load := func(relatedUID string, relatedID uint) (*models.ContentEntry, bool) {
+			cache.AddTags(c, cache.ContentTypeTag(relatedUID), cache.EntryTag(relatedUID, relatedID))
+			related, ok := loadPublished(relatedUID, relatedID)
+			if ok {
+				validator.addEntry(related)
 			}
+			return related, ok
 		}
-		entry.Data = populatedData
+		entry.Data = populateRelations(&entry, contentTypeUID, contentType.Schema, load,
+			func(related *models.ContentEntry) interface{} { return publicEntryMap(related) })
 	}
 
 	// Format entry with safe user data
-	entryMap := map[string]interface{}{
-		"id":            entry.ID,
-		"createdAt":     entry.CreatedAt,
-		"updatedAt":     entry.UpdatedAt,
-		"publishedAt":   entry.PublishedAt,
-		"contentTypeId": entry.ContentTypeID,
-		"data":          entry.Data,
-		"status":        entry.Status,
-	}
-	if entry.CreatedBy != nil {
-		entryMap["createdBy"] = safeUserResponse(entry.CreatedBy)
+	entryMap := publicEntryMap(&entry)
+
+	// Breadcrumb path from the root (tree-enabled content types).
+	// An entry below an unpublished or restricted ancestor is not reachable publicly.
+	if c.Query("breadcrumbs") == "true" && contentType.IsTree {
+		ancestors, err := entryAncestors(database.DB, &entry)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		breadcrumbs := make([]map[string]interface{}, 0, len(ancestors)+1)
+		for i := range ancestors {
+			cache.AddTags(c, cache.EntryTag(contentTypeUID, ancestors[i].ID))
+			if preview == nil && (ancestors[i].Status != "published" || !viewer.allows(&ancestors[i])) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+				return
+			}
+			validator.addEntry(&ancestors[i])
+			breadcrumbs = append(breadcrumbs, publicEntryMap(&ancestors[i]))
+		}
+		breadcrumbs = append(breadcrumbs, publicEntryMap(&entry))
+		entryMap["breadcrumbs"] = breadcrumbs
 	}
-	if entry.UpdatedBy != nil {
-		entryMap["updatedBy"] = safeUserResponse(entry.UpdatedBy)
+
+	// Drafts must never be stored by shared caches
+	if preview != nil {
+		c.Header("Cache-Control", "private, no-store")
+	} else if validator.notModified(c, &contentType) {
+		return
 	}
 
 	c.JSON(http.StatusOK, entryMap)
@@ -0,0 +1,536 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+var urlPatternPlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func GetRedirects(c *gin.Context) {
+	var redirects []models.Redirect
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	offset := (page - 1) * pageSize
+
+	query := database.DB.Model(&models.Redirect{})
+
+	// Search by path
+	if search := c.Query("search"); search != "" {
+		query = query.Where("from_path LIKE ? OR to_path LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	// Filter by content type
+	if contentType := c.Query("contentType"); contentType != "" {
+		query = query.Where("content_type_uid = ?", contentType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	if err := query.Offset(offset).Limit(pageSize).
+		Preload("CreatedBy").
+		Order("created_at DESC").
+		Find(&redirects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": redirects,
+		"meta": gin.H{
+			"pagination": gin.H{
+				"page":     page,
+				"pageSize": pageSize,
+				"total":    total,
+			},
+		},
+	})
+}
+
+func GetRedirect(c *gin.Context) {
+	id := c.Param("id")
+	var redirect models.Redirect
+
+	if err := database.DB.Preload("CreatedBy").First(&redirect, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Redirect not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, redirect)
+}
+
+type CreateRedirectRequest struct {
+	FromPath   string `json:"fromPath" binding:"required"`
+	ToPath     string `json:"toPath"`
+	StatusCode int    `json:"statusCode"`
+}
+
+func CreateRedirect(c *gin.Context) {
+	var req CreateRedirectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.StatusCode == 0 {
+		req.StatusCode = http.StatusMovedPermanently
+	}
+
+	redirect := models.Redirect{
+		FromPath:   normalizePath(req.FromPath),
+		ToPath:     req.ToPath,
+		StatusCode: req.StatusCode,
+	}
+
+	if err := validateRedirect(&redirect); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing models.Redirect
+	if err := database.DB.Where("from_path = ?", redirect.FromPath).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Redirect for this path already exists"})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	userID := userId.(uint)
+	redirect.CreatedByID = &userID
+
+	if err := database.DB.Create(&redirect).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "create", "redirect", &redirect.ID, "Created redirect", map[string]interface{}{
+		"fromPath":   redirect.FromPath,
+		"toPath":     redirect.ToPath,
+		"statusCode": redirect.StatusCode,
+	})
+
+	c.JSON(http.StatusCreated, redirect)
+}
+
+func UpdateRedirect(c *gin.Context) {
+	id := c.Param("id")
+	var redirect models.Redirect
+
+	if err := database.DB.First(&redirect, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Redirect not found"})
+		return
+	}
+
+	var req struct {
+		FromPath   string  `json:"fromPath"`
+		ToPath     *string `json:"toPath"`
+		StatusCode int     `json:"statusCode"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.FromPath != "" {
+		redirect.FromPath = normalizePath(req.FromPath)
+	}
+	if req.ToPath != nil {
+		redirect.ToPath = *req.ToPath
+	}
+	if req.StatusCode != 0 {
+		redirect.StatusCode = req.StatusCode
+	}
+
+	if err := validateRedirect(&redirect); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing models.Redirect
+	if err := database.DB.Where("from_path = ? AND id <> ?", redirect.FromPath, redirect.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Redirect for this path already exists"})
+		return
+	}
+
+	// An edited rule is owned by the admin from now on
+	redirect.IsAutomatic = false
+
+	if err := database.DB.Save(&redirect).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "update", "redirect", &redirect.ID, "Updated redirect", map[string]interface{}{
+		"fromPath":   redirect.FromPath,
+		"toPath":     redirect.ToPath,
+		"statusCode": redirect.StatusCode,
+	})
+
+	c.JSON(http.StatusOK, redirect)
+}
+
+func DeleteRedirect(c *gin.Context) {
+	id := c.Param("id")
+	var redirect models.Redirect
+
+	if err := database.DB.First(&redirect, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Redirect not found"})
+		return
+	}
+
+	// Hard delete so the path can be registered again
+	if err := database.DB.Unscoped().Delete(&redirect).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "delete", "redirect", &redirect.ID, "Deleted redirect", map[string]interface{}{
+		"fromPath": redirect.FromPath,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Redirect deleted successfully"})
+}
+
+// ResolveRedirect tells the frontend where an old path lives now.
+// Query by path (?path=/blog/old-slug) or by slug (?contentType=articles&slug=old-slug).
+// Responds with statusCode 200 (path is live), 301/302 with location, 410 Gone or 404.
+func ResolveRedirect(c *gin.Context) {
+	if path := c.Query("path"); path != "" {
+		resolvePath(c, normalizePath(path))
+		return
+	}
+
+	contentTypeUID := c.Query("contentType")
+	slug := c.Query("slug")
+	if contentTypeUID == "" || slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path or contentType and slug are required"})
+		return
+	}
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ? AND is_visible = ?", contentTypeUID, true).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	if !middleware.CheckContentTypeAccess(contentTypeUID, c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	resolveSlug(c, &contentType, slug, "")
+}
+
+func resolvePath(c *gin.Context, path string) {
+	var redirect models.Redirect
+	if err := database.DB.Where("from_path = ?", path).First(&redirect).Error; err == nil {
+		if redirect.StatusCode == http.StatusGone {
+			respondGone(c, path)
+			return
+		}
+		if redirect.EntryID != nil {
+			var entry models.ContentEntry
+			if err := database.DB.Where("id = ? AND status = ?", *redirect.EntryID, "published").First(&entry).Error; err != nil {
+				respondGone(c, path)
+				return
+			}
+			if !currentEntryViewer(c).allows(&entry) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Path not found"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"path":       path,
+			"statusCode": redirect.StatusCode,
+			"location":   redirect.ToPath,
+		})
+		return
+	}
+
+	// No explicit rule: match the URL patterns of content types
+	var contentTypes []models.ContentType
+	database.DB.Where("url_pattern <> '' AND is_visible = ?", true).Find(&contentTypes)
+	for i := range contentTypes {
+		values, ok := matchURLPattern(contentTypes[i].URLPattern, path)
+		if !ok {
+			continue
+		}
+		if !middleware.CheckContentTypeAccess(contentTypes[i].UID, c) {
+			continue
+		}
+
+		if id, ok := values["id"]; ok {
+			// Deleted entries are gone, IDs that never existed are unknown
+			var entry models.ContentEntry
+			if err := database.DB.Unscoped().Where("id = ? AND content_type_id = ?", id, contentTypes[i].ID).First(&entry).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Path not found"})
+				return
+			}
+			if entry.DeletedAt.Valid {
+				respondGone(c, path)
+				return
+			}
+			respondEntryLocation(c, path, &contentTypes[i], &entry, false)
+			return
+		}
+
+		for _, fieldName := range slugFieldNames(contentTypes[i].Schema) {
+			if slug, ok := values[fieldName]; ok {
+				resolveSlug(c, &contentTypes[i], slug, path)
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "Path not found"})
+}
+
+// resolveSlug resolves a current or previous slug of an entry
+func resolveSlug(c *gin.Context, contentType *models.ContentType, slug, path string) {
+	var entry models.ContentEntry
+	if query := whereSlug(database.DB.Where("content_type_id = ?", contentType.ID), contentType.Schema, slug); query != nil {
+		if err := query.First(&entry).Error; err == nil {
+			respondEntryLocation(c, path, contentType, &entry, true)
+			return
+		}
+	}
+
+	var history models.SlugHistory
+	if err := database.DB.Where("content_type_uid = ? AND slug = ?", contentType.UID, slug).
+		Order("created_at DESC").
+		First(&history).Error; err != nil {
+		// The current slug of a deleted entry is gone
+		if query := whereSlug(database.DB.Unscoped().Where("content_type_id = ? AND deleted_at IS NOT NULL", contentType.ID), contentType.Schema, slug); query != nil {
+			if err := query.First(&entry).Error; err == nil {
+				respondGone(c, path)
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Path not found"})
+		return
+	}
+
+	if err := database.DB.Where("id = ? AND content_type_id = ?", history.ContentEntryID, contentType.ID).First(&entry).Error; err != nil {
+		respondGone(c, path)
+		return
+	}
+	respondEntryLocation(c, path, contentType, &entry, false)
+}
+
+// respondEntryLocation answers with the entry's current location, 410 when it has been
+// unpublished, or 404 for a draft that was never published and for a restricted entry the
+// viewer may not read. currentSlug tells that the entry was found by its current slug: a
+// slug lookup without a path is then live. Without a location to move to the answer is
+// 200 as well.
+func respondEntryLocation(c *gin.Context, path string, contentType *models.ContentType, entry *models.ContentEntry, currentSlug bool) {
+	// Like the public entry API, restricted entries do not exist for other viewers
+	if !currentEntryViewer(c).allows(entry) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Path not found"})
+		return
+	}
+
+	if entry.Status != "published" {
+		// Drafts were never public, answering 410 would tell that they exist
+		if entry.PublishedAt == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Path not found"})
+			return
+		}
+		respondGone(c, path)
+		return
+	}
+
+	location := renderURLPattern(contentType.URLPattern, entry)
+	statusCode := http.StatusMovedPermanently
+	if location == "" || location == path || (path == "" && currentSlug) {
+		statusCode = http.StatusOK
+	}
+
+	response := gin.H{
+		"path":        path,
+		"statusCode":  statusCode,
+		"location":    location,
+		"contentType": contentType.UID,
+		"entryId":     entry.ID,
+	}
+	if fields := slugFieldNames(contentType.Schema); len(fields) > 0 {
+		response["slug"] = entry.Data[fields[0]]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func respondGone(c *gin.Context, path string) {
+	c.JSON(http.StatusGone, gin.H{
+		"path":       path,
+		"statusCode": http.StatusGone,
+		"error":      "Gone",
+	})
+}
+
+// recordSlugChanges stores previous slugs of an entry and keeps automatic redirects
+// pointing at the entry's current path
+func recordSlugChanges(db *gorm.DB, contentType *models.ContentType, entry *models.ContentEntry, previousData models.JSONB, userID *uint) error {
+	for _, fieldName := range slugFieldNames(contentType.Schema) {
+		previous, _ := previousData[fieldName].(string)
+		current, _ := entry.Data[fieldName].(string)
+		if previous == "" || previous == current {
+			continue
+		}
+
+		if err := db.Create(&models.SlugHistory{
+			ContentTypeUID: contentType.UID,
+			ContentEntryID: entry.ID,
+			FieldName:      fieldName,
+			Slug:           previous,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	if contentType.URLPattern == "" {
+		return nil
+	}
+
+	previousEntry := *entry
+	previousEntry.Data = previousData
+	oldPath := renderURLPattern(contentType.URLPattern, &previousEntry)
+	newPath := renderURLPattern(contentType.URLPattern, entry)
+	if oldPath == "" || newPath == "" || oldPath == newPath {
+		return nil
+	}
+
+	// The new path is live again, drop a rule that would shadow it
+	if err := db.Unscoped().Where("from_path = ? AND is_automatic = ?", newPath, true).Delete(&models.Redirect{}).Error; err != nil {
+		return err
+	}
+
+	// Avoid redirect chains: earlier automatic rules now point to the new path
+	if err := db.Model(&models.Redirect{}).
+		Where("entry_id = ? AND content_type_uid = ? AND is_automatic = ?", entry.ID, contentType.UID, true).
+		Update("to_path", newPath).Error; err != nil {
+		return err
+	}
+
+	var count int64
+	db.Model(&models.Redirect{}).Where("from_path = ?", oldPath).Count(&count)
+	if count > 0 {
+		// Keep rules edited by admins untouched
+		return nil
+	}
+
+	return db.Create(&models.Redirect{
+		FromPath:       oldPath,
+		ToPath:         newPath,
+		StatusCode:     http.StatusMovedPermanently,
+		ContentTypeUID: contentType.UID,
+		EntryID:        &entry.ID,
+		IsAutomatic:    true,
+		CreatedByID:    userID,
+	}).Error
+}
+
+func validateRedirect(redirect *models.Redirect) error {
+	switch redirect.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound:
+		if redirect.ToPath == "" {
+			return fmt.Errorf("toPath is required for status %d", redirect.StatusCode)
+		}
+	case http.StatusGone:
+		redirect.ToPath = ""
+	default:
+		return fmt.Errorf("unsupported status code %d, use 301, 302 or 410", redirect.StatusCode)
+	}
+
+	if redirect.FromPath == "/" || redirect.FromPath == redirect.ToPath {
+		return fmt.Errorf("invalid fromPath %q", redirect.FromPath)
+	}
+
+	return nil
+}
+
+// normalizePath strips the query string and trailing slash and ensures a leading slash
+func normalizePath(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// renderURLPattern fills {id} and {field} placeholders of a URL pattern from an entry.
+// Returns an empty string when a placeholder has no value.
+func renderURLPattern(pattern string, entry *models.ContentEntry) string {
+	if pattern == "" {
+		return ""
+	}
+
+	missing := false
+	path := urlPatternPlaceholder.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if name == "id" {
+			return strconv.FormatUint(uint64(entry.ID), 10)
+		}
+		switch value := entry.Data[name].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		missing = true
+		return ""
+	})
+
+	if missing {
+		return ""
+	}
+	return normalizePath(path)
+}
+
+// matchURLPattern matches a path against a URL pattern and returns the placeholder values
+func matchURLPattern(pattern, path string) (map[string]string, bool) {
+	pattern = normalizePath(pattern)
+
+	var expr strings.Builder
+	var names []string
+	last := 0
+	for _, loc := range urlPatternPlaceholder.FindAllStringSubmatchIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		expr.WriteString("([^/]+)")
+		names = append(names, pattern[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+
+	re, err := regexp.Compile("^" + expr.String() + "$")
+	if err != nil {
+		return nil, false
+	}
+
+	match := re.FindStringSubmatch(path)
+	if match == nil {
+		return nil, false
+	}
+
+	values := make(map[string]string, len(names))
+	for i, name := range names {
+		values[name] = match[i+1]
+	}
+	return values, true
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/lifecycle"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+// entryRef identifies an entry across content types
+type entryRef struct {
+	ContentTypeUID string `json:"contentType"`
+	EntryID        uint   `json:"entryId"`
+}
+
+// deleteBlocker is a relation with onDelete "restrict" that prevents a delete
+type deleteBlocker struct {
+	ContentTypeUID       string `json:"contentType"`
+	EntryID              uint   `json:"entryId"`
+	FieldName            string `json:"field"`
+	TargetContentTypeUID string `json:"targetContentType"`
+	TargetEntryID        uint   `json:"targetEntryId"`
+}
+
+// entryDeletion is the outcome of deleting entries with the onDelete actions of the
+// relations pointing to them applied: every entry to delete (cascade), the entries
+// that only lose relations (set-null, detach) and the relations blocking the delete (restrict).
+type entryDeletion struct {
+	Entries  []models.ContentEntry
+	Detached []entryRef
+	Blockers []deleteBlocker
+
+	// Content types deleted as a whole: their tree structure is not maintained
+	deletedTypes map[string]bool
+
+	contentTypes map[string]*models.ContentType
+	contentUIDs  map[uint]string
+}
+
+// planEntryDeletion resolves which entries a delete of roots affects. Nothing is written.
+func planEntryDeletion(db *gorm.DB, roots []entryRef, deletedTypes ...string) (*entryDeletion, error) {
+	d := &entryDeletion{
+		deletedTypes: make(map[string]bool),
+		contentTypes: make(map[string]*models.ContentType),
+		contentUIDs:  make(map[uint]string),
+	}
+	for _, uid := range deletedTypes {
+		d.deletedTypes[uid] = true
+	}
+
+	deleting := make(map[entryRef]bool)
+	queue := make([]entryRef, 0, len(roots))
+	for _, ref := range roots {
+		if !deleting[ref] {
+			deleting[ref] = true
+			queue = append(queue, ref)
+		}
+	}
+
+	var blockers []deleteBlocker
+	var detached []entryRef
+
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+
+		entry, err := d.loadEntry(db, ref)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+		d.Entries = append(d.Entries, *entry)
+
+		var relations []models.ContentRelation
+		if err := db.Where("target_content_type_uid = ? AND target_entry_id = ?", ref.ContentTypeUID, ref.EntryID).
+			Order("id ASC").Find(&relations).Error; err != nil {
+			return nil, err
+		}
+
+		for _, relation := range relations {
+			source := entryRef{ContentTypeUID: relation.SourceContentTypeUID, EntryID: relation.SourceEntryID}
+			if deleting[source] {
+				continue
+			}
+
+			onDelete := "detach"
+			if sourceType := d.contentType(db, source.ContentTypeUID); sourceType != nil {
+				if field, ok := relationField(sourceType.Schema, relation.SourceFieldName); ok {
+					onDelete = field.OnDelete
+				}
+			}
+
+			switch onDelete {
+			case "cascade":
+				deleting[source] = true
+				queue = append(queue, source)
+			case "restrict":
+				blockers = append(blockers, deleteBlocker{
+					ContentTypeUID:       source.ContentTypeUID,
+					EntryID:              source.EntryID,
+					FieldName:            relation.SourceFieldName,
+					TargetContentTypeUID: ref.ContentTypeUID,
+					TargetEntryID:        ref.EntryID,
+				})
+			default:
+				detached = append(detached, source)
+			}
+		}
+	}
+
+	// A blocking or detached entry may have been reached by a cascade later on
+	for _, blocker := range blockers {
+		source := entryRef{ContentTypeUID: blocker.ContentTypeUID, EntryID: blocker.EntryID}
+		if deleting[source] {
+			continue
+		}
+		if entry, _ := d.loadEntry(db, source); entry != nil {
+			d.Blockers = append(d.Blockers, blocker)
+		}
+	}
+	seen := make(map[entryRef]bool)
+	for _, source := range detached {
+		if !deleting[source] && !seen[source] {
+			seen[source] = true
+			d.Detached = append(d.Detached, source)
+		}
+	}
+
+	return d, nil
+}
+
+// execute deletes the planned entries and their relations and touches detached entries.
+// The delete lifecycle hooks run for every deleted entry.
+func (d *entryDeletion) execute(db *gorm.DB, c *gin.Context) error {
+	for i := range d.Entries {
+		entry := &d.Entries[i]
+		uid := d.contentUIDs[entry.ContentTypeID]
+
+		if err := runLifecycleHooks(db, c, lifecycle.BeforeDeleteAction, d.contentTypes[uid], entry, nil); err != nil {
+			return err
+		}
+
+		if err := db.Where("(source_content_type_uid = ? AND source_entry_id = ?) OR (target_content_type_uid = ? AND target_entry_id = ?)",
+			uid, entry.ID, uid, entry.ID).Delete(&models.ContentRelation{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("content_type_uid = ? AND entry_id = ?", uid, entry.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("content_entry_id = ?", entry.ID).Delete(&models.EntryAccess{}).Error; err != nil {
+			return err
+		}
+		if err := db.Delete(entry).Error; err != nil {
+			return err
+		}
+
+		// Keep the tree connected: children move up to the deleted entry's parent
+		if contentType := d.contentTypes[uid]; contentType.IsTree && !d.deletedTypes[uid] {
+			if err := liftTreeChildren(db, entry); err != nil {
+				return err
+			}
+		}
+
+		if err := runLifecycleHooks(db, c, lifecycle.AfterDeleteAction, d.contentTypes[uid], entry, nil); err != nil {
+			return err
+		}
+		if err := queueEntryEvents(db, c, d.contentTypes[uid], entry, webhooks.EntryDelete); err != nil {
+			return err
+		}
+	}
+
+	// Entries that lost a relation are modified
+	now := time.Now()
+	for _, ref := range d.Detached {
+		contentType := d.contentType(db, ref.ContentTypeUID)
+		if contentType == nil {
+			continue
+		}
+		if err := db.Model(&models.ContentEntry{}).
+			Where("id = ? AND content_type_id = ?", ref.EntryID, contentType.ID).
+			Update("updated_at", now).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleted lists the deleted entries
+func (d *entryDeletion) deleted() []entryRef {
+	refs := make([]entryRef, len(d.Entries))
+	for i, entry := range d.Entries {
+		refs[i] = entryRef{ContentTypeUID: d.contentUIDs[entry.ContentTypeID], EntryID: entry.ID}
+	}
+	return refs
+}
+
+// invalidateCache drops cached public responses of every affected entry
+func (d *entryDeletion) invalidateCache() {
+	for _, ref := range append(d.deleted(), d.Detached...) {
+		invalidateEntryCache(ref.ContentTypeUID, ref.EntryID)
+		if contentType := d.contentTypes[ref.ContentTypeUID]; contentType != nil && contentType.IsTree {
+			cache.Invalidate(cache.ContentTypeTag(ref.ContentTypeUID))
+		}
+	}
+}
+
+// respondBlocked answers 409 Conflict listing the entries that block a delete
+func (d *entryDeletion) respondBlocked(c *gin.Context) {
+	respondEntryWriteError(c, d.blockedError())
+}
+
+// blockedError refuses a delete with 409 Conflict, listing the entries that block it
+func (d *entryDeletion) blockedError() error {
+	return &entryRequestError{status: http.StatusConflict, message: "Entry is referenced by other entries", blockers: d.Blockers}
+}
+
+func (d *entryDeletion) contentType(db *gorm.DB, uid string) *models.ContentType {
+	if contentType, ok := d.contentTypes[uid]; ok {
+		return contentType
+	}
+
+	var contentType models.ContentType
+	if err := db.Where("uid = ?", uid).First(&contentType).Error; err != nil {
+		d.contentTypes[uid] = nil
+		return nil
+	}
+	d.contentTypes[uid] = &contentType
+	d.contentUIDs[contentType.ID] = uid
+	return &contentType
+}
+
+func (d *entryDeletion) loadEntry(db *gorm.DB, ref entryRef) (*models.ContentEntry, error) {
+	contentType := d.contentType(db, ref.ContentTypeUID)
+	if contentType == nil {
+		return nil, nil
+	}
+
+	var entry models.ContentEntry
+	err := db.Where("id = ? AND content_type_id = ?", ref.EntryID, contentType.ID).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
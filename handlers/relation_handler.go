@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
 	"github.com/xivercms/xivercms/database"
 	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
 )
 
 // GetRelations returns relations for a content entry
@@ -22,7 +25,7 @@ func GetRelations(c *gin.Context) {
 	}
 
 	var relations []models.ContentRelation
-	if err := query.Order("order ASC").Find(&relations).Error; err != nil {
+	if err := orderedRelations(query.Order("source_field_name ASC")).Find(&relations).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -47,10 +50,6 @@ func CreateRelation(c *gin.Context) {
 		return
 	}
 
-	if req.RelationType == "" {
-		req.RelationType = "manyToOne"
-	}
-
 	// Convert entryID from string to uint
 	entryIDUint, err := strconv.ParseUint(entryID, 10, 32)
 	if err != nil {
@@ -58,6 +57,37 @@ func CreateRelation(c *gin.Context) {
 		return
 	}
 
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.ContentEntry{}).Where("id = ? AND content_type_id = ?", entryIDUint, contentType.ID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+
+	// The relation must match the field definition in the schema
+	field, ok := relationField(contentType.Schema, req.FieldName)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid relation", "fields": gin.H{req.FieldName: "not a relation field"}})
+		return
+	}
+	if req.RelationType == "" {
+		req.RelationType = field.RelationType
+	}
+	if fieldError := createRelationError(contentTypeUID, uint(entryIDUint), field, req.TargetContentTypeUID, req.TargetEntryID, req.RelationType); fieldError != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid relation", "fields": gin.H{req.FieldName: fieldError}})
+		return
+	}
+
+	var order int64
+	database.DB.Model(&models.ContentRelation{}).Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+		contentTypeUID, entryIDUint, req.FieldName).Count(&order)
+
 	relation := models.ContentRelation{
 		SourceContentTypeUID: contentTypeUID,
 		SourceEntryID:        uint(entryIDUint),
@@ -65,6 +95,7 @@ func CreateRelation(c *gin.Context) {
 		TargetContentTypeUID: req.TargetContentTypeUID,
 		TargetEntryID:        req.TargetEntryID,
 		RelationType:         req.RelationType,
+		Order:                int(order),
 	}
 
 	if err := database.DB.Create(&relation).Error; err != nil {
@@ -72,6 +103,10 @@ func CreateRelation(c *gin.Context) {
 		return
 	}
 
+	// Both sides: the target shows the relation through inverse fields
+	invalidateEntryCache(contentTypeUID, relation.SourceEntryID)
+	cache.Invalidate(cache.EntryTag(relation.TargetContentTypeUID, relation.TargetEntryID))
+
 	c.JSON(http.StatusCreated, relation)
 }
 
@@ -81,12 +116,27 @@ func DeleteRelation(c *gin.Context) {
 	entryID := c.Param("id")
 	relationID := c.Param("relationId")
 
+	var relation models.ContentRelation
 	if err := database.DB.Where("id = ? AND source_content_type_uid = ? AND source_entry_id = ?",
-		relationID, contentTypeUID, entryID).Delete(&models.ContentRelation{}).Error; err != nil {
+		relationID, contentTypeUID, entryID).First(&relation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Relation not found"})
+		return
+	}
+
+	// Remaining items of the field close the gap
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&relation).Error; err != nil {
+			return err
+		}
+		return renumberRelations(tx, contentTypeUID, relation.SourceEntryID, relation.SourceFieldName)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	invalidateEntryCache(contentTypeUID, relation.SourceEntryID)
+	cache.Invalidate(cache.EntryTag(relation.TargetContentTypeUID, relation.TargetEntryID))
+
 	c.JSON(http.StatusOK, gin.H{"message": "Relation deleted successfully"})
 }
 
@@ -96,15 +146,32 @@ func GetRelatedEntries(c *gin.Context) {
 	entryID := c.Param("id")
 	fieldName := c.Param("field")
 
-	// Get relations
+	// Inverse fields of bidirectional relations are read from the owning side
+	var contentType models.ContentType
+	database.DB.Where("uid = ?", contentTypeUID).First(&contentType)
+	if field, ok := relationField(contentType.Schema, fieldName); ok && field.MappedBy != "" {
+		result, err := referencingEntries(contentTypeUID, entryID, field.TargetContentType, field.MappedBy)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// Get relations in stored order
 	var relations []models.ContentRelation
-	if err := database.DB.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
-		contentTypeUID, entryID, fieldName).Find(&relations).Error; err != nil {
+	if err := orderedRelations(database.DB.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+		contentTypeUID, entryID, fieldName)).Find(&relations).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get related entries
+	c.JSON(http.StatusOK, relatedEntries(relations))
+}
+
+// relatedEntries loads the target entries of relations, keeping their order
+func relatedEntries(relations []models.ContentRelation) []map[string]interface{} {
 	result := make([]map[string]interface{}, 0)
 	for _, relation := range relations {
 		var entry models.ContentEntry
@@ -116,6 +183,284 @@ func GetRelatedEntries(c *gin.Context) {
 			})
 		}
 	}
+	return result
+}
+
+// GetReferencingEntries returns entries whose relations point to a content entry (reverse lookup)
+func GetReferencingEntries(c *gin.Context) {
+	// Optional filters: source content type and field name
+	result, err := referencingEntries(c.Param("uid"), c.Param("id"), c.Query("contentType"), c.Query("field"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, result)
 }
+
+// referencingEntries loads the source entries of relations targeting an entry
+func referencingEntries(contentTypeUID, entryID, sourceContentTypeUID, fieldName string) ([]map[string]interface{}, error) {
+	query := database.DB.Where("target_content_type_uid = ? AND target_entry_id = ?", contentTypeUID, entryID)
+	if sourceContentTypeUID != "" {
+		query = query.Where("source_content_type_uid = ?", sourceContentTypeUID)
+	}
+	if fieldName != "" {
+		query = query.Where("source_field_name = ?", fieldName)
+	}
+
+	var relations []models.ContentRelation
+	if err := query.Order("source_content_type_uid ASC, source_entry_id ASC").Find(&relations).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for _, relation := range relations {
+		var entry models.ContentEntry
+		if err := database.DB.Where("content_type_id = (SELECT id FROM content_types WHERE uid = ?) AND id = ?",
+			relation.SourceContentTypeUID, relation.SourceEntryID).First(&entry).Error; err == nil {
+			result = append(result, map[string]interface{}{
+				"entry":       entry,
+				"contentType": relation.SourceContentTypeUID,
+				"relation":    relation,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// relationField returns the definition of a relation field in a content type schema
+func relationField(schema models.JSONB, fieldName string) (models.ContentField, bool) {
+	var field models.ContentField
+	fieldMap, ok := schema[fieldName].(map[string]interface{})
+	if !ok {
+		return field, false
+	}
+	if fieldType, _ := fieldMap["type"].(string); fieldType != "relation" {
+		return field, false
+	}
+
+	field.Name = fieldName
+	field.Type = "relation"
+	field.RelationType, _ = fieldMap["relationType"].(string)
+	field.TargetContentType, _ = fieldMap["targetContentType"].(string)
+	field.InversedBy, _ = fieldMap["inversedBy"].(string)
+	field.MappedBy, _ = fieldMap["mappedBy"].(string)
+	field.OnDelete, _ = fieldMap["onDelete"].(string)
+	if field.RelationType == "" {
+		field.RelationType = "manyToOne"
+	}
+	if field.OnDelete == "" {
+		field.OnDelete = "set-null"
+		if isToManyRelation(field.RelationType) {
+			field.OnDelete = "detach"
+		}
+	}
+	return field, true
+}
+
+// inverseRelationFields returns the relation fields of a schema that are resolved from the owning side
+func inverseRelationFields(schema models.JSONB) []models.ContentField {
+	var fields []models.ContentField
+	for fieldName := range schema {
+		if field, ok := relationField(schema, fieldName); ok && field.MappedBy != "" && field.TargetContentType != "" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+func isToManyRelation(relationType string) bool {
+	return relationType == "oneToMany" || relationType == "manyToMany"
+}
+
+// relationTargetIDs extracts entry IDs from a relation field value: an ID, an object
+// with an id, or an array of those for to-many relations
+func relationTargetIDs(value interface{}, many bool) []uint {
+	toID := func(v interface{}) uint {
+		if idFloat, ok := v.(float64); ok {
+			return uint(idFloat)
+		}
+		if idMap, ok := v.(map[string]interface{}); ok {
+			if idFloat, ok := idMap["id"].(float64); ok {
+				return uint(idFloat)
+			}
+		}
+		return 0
+	}
+
+	var ids []uint
+	if many {
+		if items, ok := value.([]interface{}); ok {
+			for _, item := range items {
+				if id := toID(item); id > 0 {
+					ids = append(ids, id)
+				}
+			}
+		}
+		return ids
+	}
+
+	if id := toID(value); id > 0 {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// setInverseRelations writes an inverse relation field by updating the owning entries'
+// relations, so both sides stay in sync. Returns the cache tags of changed entries.
+func setInverseRelations(db *gorm.DB, contentTypeUID string, entryID uint, field models.ContentField, sourceIDs []uint) ([]string, error) {
+	// Relation type of the owning field decides whether an owner can point to several entries
+	ownerRelationType := "manyToOne"
+	var ownerType models.ContentType
+	if err := db.Where("uid = ?", field.TargetContentType).First(&ownerType).Error; err == nil {
+		if ownerField, ok := relationField(ownerType.Schema, field.MappedBy); ok {
+			ownerRelationType = ownerField.RelationType
+		}
+	}
+
+	keep := make(map[uint]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		keep[id] = true
+	}
+
+	var existing []models.ContentRelation
+	if err := db.Where("source_content_type_uid = ? AND source_field_name = ? AND target_content_type_uid = ? AND target_entry_id = ?",
+		field.TargetContentType, field.MappedBy, contentTypeUID, entryID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	tags := []string{cache.EntryTag(contentTypeUID, entryID)}
+	linked := make(map[uint]bool, len(existing))
+	for _, relation := range existing {
+		if keep[relation.SourceEntryID] {
+			linked[relation.SourceEntryID] = true
+			continue
+		}
+		if err := db.Delete(&relation).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, cache.EntryTag(field.TargetContentType, relation.SourceEntryID))
+	}
+
+	for _, sourceID := range sourceIDs {
+		if linked[sourceID] {
+			continue
+		}
+
+		ownerQuery := db.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+			field.TargetContentType, sourceID, field.MappedBy)
+
+		// A to-one owner is re-pointed from its previous target
+		if !isToManyRelation(ownerRelationType) {
+			var previous []models.ContentRelation
+			if err := ownerQuery.Session(&gorm.Session{}).Find(&previous).Error; err != nil {
+				return nil, err
+			}
+			for _, relation := range previous {
+				tags = append(tags, cache.EntryTag(relation.TargetContentTypeUID, relation.TargetEntryID))
+			}
+			if err := ownerQuery.Session(&gorm.Session{}).Delete(&models.ContentRelation{}).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		var order int64
+		ownerQuery.Session(&gorm.Session{}).Model(&models.ContentRelation{}).Count(&order)
+
+		if err := db.Create(&models.ContentRelation{
+			SourceContentTypeUID: field.TargetContentType,
+			SourceEntryID:        sourceID,
+			SourceFieldName:      field.MappedBy,
+			TargetContentTypeUID: contentTypeUID,
+			TargetEntryID:        entryID,
+			RelationType:         ownerRelationType,
+			Order:                int(order),
+		}).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, cache.EntryTag(field.TargetContentType, sourceID))
+	}
+
+	return tags, nil
+}
+
+// populateRelations returns the entry data with relation fields replaced by related entries,
+// including inverse fields resolved from the owning side. load fetches a related entry
+// (returning false hides it) and format converts it for the response.
+func populateRelations(entry *models.ContentEntry, contentTypeUID string, schema models.JSONB,
+	load func(contentTypeUID string, entryID uint) (*models.ContentEntry, bool),
+	format func(*models.ContentEntry) interface{}) map[string]interface{} {
+
+	populatedData := make(map[string]interface{})
+	for k, v := range entry.Data {
+		populatedData[k] = v
+	}
+
+	add := func(fieldName string, many bool, value interface{}) {
+		if !many {
+			populatedData[fieldName] = value
+			return
+		}
+		items, _ := populatedData[fieldName].([]interface{})
+		populatedData[fieldName] = append(items, value)
+	}
+
+	var relations []models.ContentRelation
+	orderedRelations(database.DB.Where("source_content_type_uid = ? AND source_entry_id = ?", contentTypeUID, entry.ID)).Find(&relations)
+
+	for _, relation := range relations {
+		if related, ok := load(relation.TargetContentTypeUID, relation.TargetEntryID); ok {
+			add(relation.SourceFieldName, isToManyRelation(relation.RelationType), format(related))
+		}
+	}
+
+	for _, field := range inverseRelationFields(schema) {
+		var inverse []models.ContentRelation
+		database.DB.Where("source_content_type_uid = ? AND source_field_name = ? AND target_content_type_uid = ? AND target_entry_id = ?",
+			field.TargetContentType, field.MappedBy, contentTypeUID, entry.ID).
+			Order("source_entry_id ASC").
+			Find(&inverse)
+
+		many := isToManyRelation(field.RelationType)
+		if many {
+			populatedData[field.Name] = []interface{}{}
+		}
+		for _, relation := range inverse {
+			if related, ok := load(relation.SourceContentTypeUID, relation.SourceEntryID); ok {
+				add(field.Name, many, format(related))
+			}
+		}
+	}
+
+	return populatedData
+}
+
+// relatedEntryLoader returns a loader for populateRelations. Content type IDs are
+// resolved once per UID. With a viewer, only published entries the viewer may read are
+// loaded; nil loads every entry, for the admin API.
+func relatedEntryLoader(viewer *entryViewer) func(contentTypeUID string, entryID uint) (*models.ContentEntry, bool) {
+	contentTypeIDs := make(map[string]uint)
+
+	return func(contentTypeUID string, entryID uint) (*models.ContentEntry, bool) {
+		contentTypeID, ok := contentTypeIDs[contentTypeUID]
+		if !ok {
+			var contentType models.ContentType
+			database.DB.Where("uid = ?", contentTypeUID).First(&contentType)
+			contentTypeID = contentType.ID
+			contentTypeIDs[contentTypeUID] = contentTypeID
+		}
+
+		query := database.DB.Where("content_type_id = ? AND id = ?", contentTypeID, entryID)
+		if viewer != nil {
+			query = viewer.scope(query.Model(&models.ContentEntry{}).Where("status = ?", "published")).Preload("CreatedBy")
+		}
+
+		var entry models.ContentEntry
+		if err := query.First(&entry).Error; err != nil {
+			return nil, false
+		}
+		return &entry, true
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReorderRelationsRequest lists the target entry IDs of a relation field in the new order
+type ReorderRelationsRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// InsertRelationRequest adds a single related entry at a position (appended when omitted)
+type InsertRelationRequest struct {
+	ID       uint `json:"id" binding:"required"`
+	Position *int `json:"position"`
+}
+
+// ReorderRelations sets the order of the related entries of a many-relation field
+func ReorderRelations(c *gin.Context) {
+	contentTypeUID, entryID, field, ok := orderableRelationField(c)
+	if !ok {
+		return
+	}
+
+	var req ReorderRelationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var relations []models.ContentRelation
+	if err := fieldRelations(database.DB, contentTypeUID, entryID, field.Name).Find(&relations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The new order must contain exactly the current related entries
+	byTarget := make(map[uint]models.ContentRelation, len(relations))
+	for _, relation := range relations {
+		byTarget[relation.TargetEntryID] = relation
+	}
+	if len(req.IDs) != len(relations) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ids must list all %d related entries", len(relations))})
+		return
+	}
+
+	ordered := make([]models.ContentRelation, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		relation, exists := byTarget[id]
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("entry %d is not related or listed more than once", id)})
+			return
+		}
+		delete(byTarget, id)
+		ordered = append(ordered, relation)
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return writeRelationOrder(tx, ordered)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invalidateEntryCache(contentTypeUID, entryID)
+
+	respondFieldRelations(c, contentTypeUID, entryID, field.Name)
+}
+
+// InsertRelation adds a related entry to a many-relation field at a position
+func InsertRelation(c *gin.Context) {
+	contentTypeUID, entryID, field, ok := orderableRelationField(c)
+	if !ok {
+		return
+	}
+
+	var req InsertRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if fieldError := createRelationError(contentTypeUID, entryID, field, field.TargetContentType, req.ID, field.RelationType); fieldError != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid relation", "fields": gin.H{field.Name: fieldError}})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var relations []models.ContentRelation
+		if err := fieldRelations(tx, contentTypeUID, entryID, field.Name).Find(&relations).Error; err != nil {
+			return err
+		}
+
+		position := len(relations)
+		if req.Position != nil && *req.Position >= 0 && *req.Position < position {
+			position = *req.Position
+		}
+
+		relation := models.ContentRelation{
+			SourceContentTypeUID: contentTypeUID,
+			SourceEntryID:        entryID,
+			SourceFieldName:      field.Name,
+			TargetContentTypeUID: field.TargetContentType,
+			TargetEntryID:        req.ID,
+			RelationType:         field.RelationType,
+			Order:                position,
+		}
+		if err := tx.Create(&relation).Error; err != nil {
+			return err
+		}
+
+		ordered := make([]models.ContentRelation, 0, len(relations)+1)
+		ordered = append(ordered, relations[:position]...)
+		ordered = append(ordered, relation)
+		ordered = append(ordered, relations[position:]...)
+		return writeRelationOrder(tx, ordered)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invalidateEntryCache(contentTypeUID, entryID)
+	cache.Invalidate(cache.EntryTag(field.TargetContentType, req.ID))
+
+	respondFieldRelations(c, contentTypeUID, entryID, field.Name)
+}
+
+// orderableRelationField resolves the :uid, :id and :field params to a to-many relation
+// field stored on the entry. Writes the error response and returns false otherwise.
+func orderableRelationField(c *gin.Context) (string, uint, models.ContentField, bool) {
+	contentTypeUID := c.Param("uid")
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return "", 0, models.ContentField{}, false
+	}
+
+	var entry models.ContentEntry
+	if err := database.DB.Where("id = ? AND content_type_id = ?", c.Param("id"), contentType.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return "", 0, models.ContentField{}, false
+	}
+
+	field, ok := relationField(contentType.Schema, c.Param("field"))
+	switch {
+	case !ok:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not a relation field"})
+	case !isToManyRelation(field.RelationType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only oneToMany and manyToMany relations are ordered"})
+	case field.MappedBy != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Inverse side of %s.%s; the order is kept on the owning side", field.TargetContentType, field.MappedBy)})
+	default:
+		return contentTypeUID, entry.ID, field, true
+	}
+	return "", 0, models.ContentField{}, false
+}
+
+func respondFieldRelations(c *gin.Context, contentTypeUID string, entryID uint, fieldName string) {
+	var relations []models.ContentRelation
+	if err := fieldRelations(database.DB, contentTypeUID, entryID, fieldName).Find(&relations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relatedEntries(relations))
+}
+
+// orderedRelations sorts relations by their stored order. "order" is a reserved word,
+// the column is quoted by the clause.
+func orderedRelations(query *gorm.DB) *gorm.DB {
+	return query.Order(clause.OrderByColumn{Column: clause.Column{Name: "order"}}).Order("id ASC")
+}
+
+// fieldRelations queries the relations of an entry field in stored order
+func fieldRelations(db *gorm.DB, contentTypeUID string, entryID uint, fieldName string) *gorm.DB {
+	return orderedRelations(db.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+		contentTypeUID, entryID, fieldName))
+}
+
+// writeRelationOrder stores the positions of relations as listed
+func writeRelationOrder(db *gorm.DB, relations []models.ContentRelation) error {
+	for i, relation := range relations {
+		if relation.Order == i {
+			continue
+		}
+		if err := db.Model(&models.ContentRelation{}).Where("id = ?", relation.ID).Update("order", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renumberRelations closes gaps in the order of an entry field's relations
+func renumberRelations(db *gorm.DB, contentTypeUID string, entryID uint, fieldName string) error {
+	var relations []models.ContentRelation
+	if err := fieldRelations(db, contentTypeUID, entryID, fieldName).Find(&relations).Error; err != nil {
+		return err
+	}
+	return writeRelationOrder(db, relations)
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+)
+
+var relationTypes = map[string]bool{
+	"oneToOne":   true,
+	"oneToMany":  true,
+	"manyToOne":  true,
+	"manyToMany": true,
+}
+
+var onDeleteActions = map[string]bool{
+	"restrict": true,
+	"cascade":  true,
+	"set-null": true,
+	"detach":   true,
+}
+
+// validateRelations checks relation field values of an entry against the content type schema:
+// value shape, target existence and cardinality. entryID is 0 for a new entry.
+// Returns an error message per invalid field, or nil.
+func validateRelations(contentTypeUID string, entryID uint, data map[string]interface{}, schema models.JSONB) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	for fieldName, value := range data {
+		field, ok := relationField(schema, fieldName)
+		if !ok {
+			continue
+		}
+
+		ids, err := parseRelationValue(value, isToManyRelation(field.RelationType))
+		if err == nil {
+			err = validateRelationField(contentTypeUID, entryID, field, ids)
+		}
+		if err != nil {
+			fieldErrors[fieldName] = err.Error()
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return fieldErrors
+}
+
+// validateRelationField checks that the targets of a relation field exist and that the
+// field's cardinality allows linking them to the entry
+func validateRelationField(contentTypeUID string, entryID uint, field models.ContentField, ids []uint) error {
+	if !relationTypes[field.RelationType] {
+		return fmt.Errorf("unknown relation type %q", field.RelationType)
+	}
+	if !onDeleteActions[field.OnDelete] {
+		return fmt.Errorf("unknown onDelete action %q", field.OnDelete)
+	}
+	if field.TargetContentType == "" {
+		return fmt.Errorf("relation field has no target content type")
+	}
+
+	var targetType models.ContentType
+	if err := database.DB.Where("uid = ?", field.TargetContentType).First(&targetType).Error; err != nil {
+		return fmt.Errorf("target content type %q not found", field.TargetContentType)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var existing []uint
+	database.DB.Model(&models.ContentEntry{}).
+		Where("content_type_id = ? AND id IN ?", targetType.ID, ids).
+		Pluck("id", &existing)
+	if missing := missingIDs(ids, existing); len(missing) > 0 {
+		return fmt.Errorf("%s entries not found: %s", field.TargetContentType, joinIDs(missing))
+	}
+
+	// Inverse side: cardinality is defined by the owning field
+	if field.MappedBy != "" {
+		ownerField, ok := relationField(targetType.Schema, field.MappedBy)
+		if !ok || ownerField.TargetContentType != contentTypeUID {
+			return fmt.Errorf("mappedBy field %s.%s is not a relation to %s", field.TargetContentType, field.MappedBy, contentTypeUID)
+		}
+		if ownerField.RelationType != "oneToOne" {
+			return nil
+		}
+
+		// A oneToOne owner must not already be linked to another entry
+		var taken []models.ContentRelation
+		database.DB.Where("source_content_type_uid = ? AND source_field_name = ? AND source_entry_id IN ? AND NOT (target_content_type_uid = ? AND target_entry_id = ?)",
+			field.TargetContentType, field.MappedBy, ids, contentTypeUID, entryID).Find(&taken)
+		if len(taken) > 0 {
+			return fmt.Errorf("%s entry %d is already linked to entry %d", field.TargetContentType, taken[0].SourceEntryID, taken[0].TargetEntryID)
+		}
+		return nil
+	}
+
+	// oneToOne and oneToMany: a target belongs to a single source entry
+	if field.RelationType == "oneToOne" || field.RelationType == "oneToMany" {
+		var taken []models.ContentRelation
+		database.DB.Where("source_content_type_uid = ? AND source_field_name = ? AND source_entry_id <> ? AND target_content_type_uid = ? AND target_entry_id IN ?",
+			contentTypeUID, field.Name, entryID, field.TargetContentType, ids).Find(&taken)
+		if len(taken) > 0 {
+			return fmt.Errorf("%s entry %d is already linked to entry %d", field.TargetContentType, taken[0].TargetEntryID, taken[0].SourceEntryID)
+		}
+	}
+
+	return nil
+}
+
+// createRelationError checks a single relation added through the relations API.
+// Returns an error message for the field, or an empty string.
+func createRelationError(contentTypeUID string, entryID uint, field models.ContentField, targetContentTypeUID string, targetEntryID uint, relationType string) string {
+	if field.MappedBy != "" {
+		return fmt.Sprintf("inverse side of %s.%s; create the relation on the owning side", field.TargetContentType, field.MappedBy)
+	}
+	if targetContentTypeUID != field.TargetContentType {
+		return fmt.Sprintf("target content type must be %s", field.TargetContentType)
+	}
+	if relationType != field.RelationType {
+		return fmt.Sprintf("relation type must be %s", field.RelationType)
+	}
+
+	var existing []models.ContentRelation
+	database.DB.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+		contentTypeUID, entryID, field.Name).Find(&existing)
+	for _, relation := range existing {
+		if relation.TargetEntryID == targetEntryID {
+			return fmt.Sprintf("already linked to %s entry %d", field.TargetContentType, targetEntryID)
+		}
+	}
+	if !isToManyRelation(field.RelationType) && len(existing) > 0 {
+		return fmt.Sprintf("%s relation already set to %s entry %d", field.RelationType, field.TargetContentType, existing[0].TargetEntryID)
+	}
+
+	if err := validateRelationField(contentTypeUID, entryID, field, []uint{targetEntryID}); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// parseRelationValue reads entry IDs from a relation field value. To-one fields take an ID,
+// an object with an id or null; to-many fields take an array of those.
+func parseRelationValue(value interface{}, many bool) ([]uint, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if !many {
+		id, err := parseRelationID(value)
+		if err != nil {
+			return nil, err
+		}
+		return []uint{id}, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of entry IDs")
+	}
+
+	ids := make([]uint, 0, len(items))
+	seen := make(map[uint]bool, len(items))
+	for _, item := range items {
+		id, err := parseRelationID(item)
+		if err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("entry %d is listed more than once", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseRelationID(value interface{}) (uint, error) {
+	if idMap, ok := value.(map[string]interface{}); ok {
+		value = idMap["id"]
+	}
+
+	id, ok := value.(float64)
+	if !ok || id < 1 || id != float64(uint(id)) {
+		if _, isArray := value.([]interface{}); isArray {
+			return 0, fmt.Errorf("expected a single entry ID")
+		}
+		return 0, fmt.Errorf("invalid entry ID %v", value)
+	}
+	return uint(id), nil
+}
+
+func missingIDs(ids, existing []uint) []uint {
+	found := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	return missing
+}
+
+func joinIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprint(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// inverseRelationTypes maps the relation type of an owning field to the type of its inverse field
+var inverseRelationTypes = map[string]string{
+	"oneToOne":   "oneToOne",
+	"oneToMany":  "manyToOne",
+	"manyToOne":  "oneToMany",
+	"manyToMany": "manyToMany",
+}
+
+// validateRelationSchema checks the relation fields of a content type being saved. Every
+// relation field needs a known relation type, an existing target content type and a known
+// onDelete action. A mappedBy field must name a relation of the target type that points
+// back and owns the relation. An inversedBy field may be saved before its inverse side
+// exists; once the target has that field, it must be mapped by this one.
+func validateRelationSchema(contentType *models.ContentType) error {
+	names := make([]string, 0, len(contentType.Schema))
+	for name := range contentType.Schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := relationField(contentType.Schema, name)
+		if !ok {
+			continue
+		}
+		if !relationTypes[field.RelationType] {
+			return fmt.Errorf("relation field %s: unknown relation type %q", name, field.RelationType)
+		}
+		if field.TargetContentType == "" {
+			return fmt.Errorf("relation field %s has no target content type", name)
+		}
+		// Entry deletion treats an unknown action as detach, so it is rejected here
+		if !onDeleteActions[field.OnDelete] {
+			return fmt.Errorf("relation field %s: unknown onDelete action %q", name, field.OnDelete)
+		}
+
+		// Self-referencing relations are checked against the schema being saved
+		targetSchema := contentType.Schema
+		if field.TargetContentType != contentType.UID {
+			var targetType models.ContentType
+			if err := database.DB.Where("uid = ?", field.TargetContentType).First(&targetType).Error; err != nil {
+				return fmt.Errorf("relation field %s: target content type %q not found", name, field.TargetContentType)
+			}
+			targetSchema = targetType.Schema
+		}
+
+		if field.MappedBy == "" && field.InversedBy == "" {
+			continue
+		}
+		if field.MappedBy != "" && field.InversedBy != "" {
+			return fmt.Errorf("relation field %s cannot have both mappedBy and inversedBy", name)
+		}
+
+		if field.MappedBy != "" {
+			owner, ok := relationField(targetSchema, field.MappedBy)
+			if !ok || owner.TargetContentType != contentType.UID || owner.MappedBy != "" {
+				return fmt.Errorf("relation field %s: mappedBy field %s.%s must be an owning relation to %s", name, field.TargetContentType, field.MappedBy, contentType.UID)
+			}
+			if owner.InversedBy != "" && owner.InversedBy != name {
+				return fmt.Errorf("relation field %s: %s.%s is inversed by %s", name, field.TargetContentType, field.MappedBy, owner.InversedBy)
+			}
+			if expected := inverseRelationTypes[owner.RelationType]; field.RelationType != expected {
+				return fmt.Errorf("relation field %s: relation type must be %s to map %s %s.%s", name, expected, owner.RelationType, field.TargetContentType, field.MappedBy)
+			}
+			continue
+		}
+
+		inverse, ok := relationField(targetSchema, field.InversedBy)
+		if !ok {
+			if _, exists := targetSchema[field.InversedBy]; exists {
+				return fmt.Errorf("relation field %s: inversedBy field %s.%s is not a relation", name, field.TargetContentType, field.InversedBy)
+			}
+			continue
+		}
+		if inverse.TargetContentType != contentType.UID || inverse.MappedBy != name {
+			return fmt.Errorf("relation field %s: inversedBy field %s.%s must be mapped by %s.%s", name, field.TargetContentType, field.InversedBy, contentType.UID, name)
+		}
+		if expected := inverseRelationTypes[field.RelationType]; inverse.RelationType != expected {
+			return fmt.Errorf("relation field %s: inverse field %s.%s must be %s", name, field.TargetContentType, field.InversedBy, expected)
+		}
+	}
+	return nil
+}
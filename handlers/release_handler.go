@@ -0,0 +1,782 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/lifecycle"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+// releasePollInterval is how often the scheduler looks for releases that are due
+const releasePollInterval = 15 * time.Second
+
+var releaseActions = map[string]bool{"publish": true, "unpublish": true}
+
+// errReleaseNotExecutable is returned when a release was executed or reverted concurrently
+var errReleaseNotExecutable = errors.New("release is not in a state that allows this")
+
+// errReleaseNotEditable is returned when a release was executed while it was being edited
+var errReleaseNotEditable = errors.New("release has already been executed")
+
+// releaseProblem is why an action of a release cannot be executed
+type releaseProblem struct {
+	ActionID    uint              `json:"actionId"`
+	ContentType string            `json:"contentType"`
+	EntryID     uint              `json:"entryId"`
+	Error       string            `json:"error"`
+	Fields      map[string]string `json:"fields,omitempty"`
+}
+
+// releaseValidationError rejects the execution of a release with invalid actions
+type releaseValidationError struct {
+	Problems []releaseProblem
+}
+
+func (e *releaseValidationError) Error() string {
+	return fmt.Sprintf("%d action(s) cannot be executed", len(e.Problems))
+}
+
+type ReleaseActionRequest struct {
+	ContentType string `json:"contentType" binding:"required"`
+	EntryID     uint   `json:"entryId" binding:"required"`
+	Action      string `json:"action" binding:"required"`
+}
+
+type CreateReleaseRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	ScheduledAt *time.Time             `json:"scheduledAt"`
+	Actions     []ReleaseActionRequest `json:"actions"`
+}
+
+type UpdateReleaseRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	// A time schedules the release, null removes the schedule, omitted keeps it
+	ScheduledAt json.RawMessage `json:"scheduledAt"`
+}
+
+func GetReleases(c *gin.Context) {
+	var releases []models.Release
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	offset := (page - 1) * pageSize
+
+	query := database.DB.Model(&models.Release{})
+
+	// Filter by status
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Releases containing an entry
+	if uid, entryID := c.Query("contentType"), c.Query("entryId"); uid != "" && entryID != "" {
+		query = query.Where("id IN (?)", database.DB.Model(&models.ReleaseAction{}).Select("release_id").
+			Where("content_type_uid = ? AND entry_id = ?", uid, entryID))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	if err := query.Offset(offset).Limit(pageSize).
+		Preload("Actions").Preload("CreatedBy").
+		Order("created_at DESC").
+		Find(&releases).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": releases,
+		"meta": gin.H{
+			"pagination": gin.H{
+				"page":     page,
+				"pageSize": pageSize,
+				"total":    total,
+			},
+		},
+	})
+}
+
+func GetRelease(c *gin.Context) {
+	release, ok := findRelease(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, release)
+}
+
+func CreateRelease(c *gin.Context) {
+	var req CreateReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ScheduledAt != nil && !req.ScheduledAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scheduledAt must be in the future"})
+		return
+	}
+
+	actions, err := buildReleaseActions(req.Actions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	userID := userId.(uint)
+
+	release := models.Release{
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      models.ReleasePending,
+		ScheduledAt: req.ScheduledAt,
+		CreatedByID: &userID,
+		Actions:     actions,
+	}
+
+	if err := database.DB.Create(&release).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "create", "release", &release.ID, "Created release", map[string]interface{}{
+		"name":        release.Name,
+		"actions":     len(release.Actions),
+		"scheduledAt": release.ScheduledAt,
+	})
+
+	c.JSON(http.StatusCreated, release)
+}
+
+func UpdateRelease(c *gin.Context) {
+	release, ok := findEditableRelease(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		release.Name = req.Name
+	}
+	if req.Description != nil {
+		release.Description = *req.Description
+	}
+	if len(req.ScheduledAt) > 0 {
+		var scheduledAt *time.Time
+		if err := json.Unmarshal(req.ScheduledAt, &scheduledAt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduledAt"})
+			return
+		}
+		if scheduledAt != nil && !scheduledAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scheduledAt must be in the future"})
+			return
+		}
+		release.ScheduledAt = scheduledAt
+	}
+
+	// Editing a failed release makes it pending again
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return updateEditableRelease(tx, release.ID, map[string]interface{}{
+			"name":         release.Name,
+			"description":  release.Description,
+			"scheduled_at": release.ScheduledAt,
+			"status":       models.ReleasePending,
+			"error":        "",
+		})
+	}); err != nil {
+		respondReleaseError(c, err)
+		return
+	}
+	release, _ = loadRelease(release.ID)
+
+	CreateAuditLog(c, "update", "release", &release.ID, "Updated release", map[string]interface{}{
+		"name":        release.Name,
+		"scheduledAt": release.ScheduledAt,
+	})
+
+	c.JSON(http.StatusOK, release)
+}
+
+func DeleteRelease(c *gin.Context) {
+	release, ok := findRelease(c)
+	if !ok {
+		return
+	}
+
+	// Actions of executed releases are kept with the release for its audit trail
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if release.Status == models.ReleasePending || release.Status == models.ReleaseFailed {
+			if err := tx.Where("release_id = ?", release.ID).Delete(&models.ReleaseAction{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(release).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "delete", "release", &release.ID, "Deleted release", map[string]interface{}{
+		"name": release.Name,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Release deleted successfully"})
+}
+
+// AddReleaseActions adds entries to a release. An entry already in the release gets the new action.
+func AddReleaseActions(c *gin.Context) {
+	release, ok := findEditableRelease(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Actions []ReleaseActionRequest `json:"actions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actions, err := buildReleaseActions(req.Actions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateEditableRelease(tx, release.ID, map[string]interface{}{}); err != nil {
+			return err
+		}
+		for _, action := range actions {
+			if err := tx.Where("release_id = ? AND content_type_uid = ? AND entry_id = ?", release.ID, action.ContentTypeUID, action.EntryID).
+				Delete(&models.ReleaseAction{}).Error; err != nil {
+				return err
+			}
+			action.ReleaseID = release.ID
+			if err := tx.Create(&action).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		respondReleaseError(c, err)
+		return
+	}
+
+	release, _ = loadRelease(release.ID)
+	c.JSON(http.StatusOK, release)
+}
+
+func DeleteReleaseAction(c *gin.Context) {
+	release, ok := findEditableRelease(c)
+	if !ok {
+		return
+	}
+
+	var deleted int64
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateEditableRelease(tx, release.ID, map[string]interface{}{}); err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND release_id = ?", c.Param("actionId"), release.ID).Delete(&models.ReleaseAction{})
+		deleted = result.RowsAffected
+		return result.Error
+	}); err != nil {
+		respondReleaseError(c, err)
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Release action not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Release action deleted successfully"})
+}
+
+// ValidateRelease reports whether every action of a release can be executed
+func ValidateRelease(c *gin.Context) {
+	release, ok := findRelease(c)
+	if !ok {
+		return
+	}
+
+	problems := validateReleaseActions(release.Actions)
+	c.JSON(http.StatusOK, gin.H{"valid": len(problems) == 0, "problems": problems})
+}
+
+// ExecuteRelease runs all actions of a release now, in one transaction
+func ExecuteRelease(c *gin.Context) {
+	release, ok := findEditableRelease(c)
+	if !ok {
+		return
+	}
+
+	if err := executeRelease(c, release, false); err != nil {
+		respondReleaseError(c, err)
+		return
+	}
+
+	release, _ = loadRelease(release.ID)
+	c.JSON(http.StatusOK, release)
+}
+
+// RevertRelease restores the status every entry of an executed release had before it
+func RevertRelease(c *gin.Context) {
+	release, ok := findRelease(c)
+	if !ok {
+		return
+	}
+	if release.Status != models.ReleaseDone {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only executed releases can be reverted"})
+		return
+	}
+
+	if err := revertRelease(c, release); err != nil {
+		respondReleaseError(c, err)
+		return
+	}
+
+	release, _ = loadRelease(release.ID)
+	c.JSON(http.StatusOK, release)
+}
+
+func loadRelease(id interface{}) (*models.Release, error) {
+	var release models.Release
+	err := database.DB.Preload("Actions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("CreatedBy").First(&release, id).Error
+	return &release, err
+}
+
+func findRelease(c *gin.Context) (*models.Release, bool) {
+	release, err := loadRelease(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+		return nil, false
+	}
+	return release, true
+}
+
+// findEditableRelease loads a release that has not been executed yet
+func findEditableRelease(c *gin.Context) (*models.Release, bool) {
+	release, ok := findRelease(c)
+	if !ok {
+		return nil, false
+	}
+	if release.Status != models.ReleasePending && release.Status != models.ReleaseFailed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Release has already been executed"})
+		return nil, false
+	}
+	return release, true
+}
+
+// updateEditableRelease writes a release only while it is pending or failed. Edits run in
+// the transaction of this write, so an execution claiming the release at the same time
+// either waits for them or makes the edit fail with errReleaseNotEditable.
+func updateEditableRelease(tx *gorm.DB, releaseID uint, values map[string]interface{}) error {
+	values["updated_at"] = time.Now()
+	result := tx.Model(&models.Release{}).
+		Where("id = ? AND status IN ?", releaseID, []string{models.ReleasePending, models.ReleaseFailed}).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errReleaseNotEditable
+	}
+	return nil
+}
+
+func respondReleaseError(c *gin.Context, err error) {
+	var invalid *releaseValidationError
+	switch {
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Release cannot be executed", "problems": invalid.Problems})
+	case errors.Is(err, errReleaseNotEditable):
+		c.JSON(http.StatusConflict, gin.H{"error": "Release has already been executed"})
+	case errors.Is(err, errReleaseNotExecutable):
+		c.JSON(http.StatusConflict, gin.H{"error": "Release was executed or reverted concurrently"})
+	default:
+		respondEntryWriteError(c, err)
+	}
+}
+
+// buildReleaseActions checks the requested actions, the entries must exist
+func buildReleaseActions(requests []ReleaseActionRequest) ([]models.ReleaseAction, error) {
+	actions := make([]models.ReleaseAction, 0, len(requests))
+	seen := make(map[string]bool)
+	for _, req := range requests {
+		if !releaseActions[req.Action] {
+			return nil, fmt.Errorf("unknown action %q, use publish or unpublish", req.Action)
+		}
+		key := fmt.Sprintf("%s:%d", req.ContentType, req.EntryID)
+		if seen[key] {
+			return nil, fmt.Errorf("entry %d of %s is listed twice", req.EntryID, req.ContentType)
+		}
+		seen[key] = true
+
+		var contentType models.ContentType
+		if err := database.DB.Where("uid = ?", req.ContentType).First(&contentType).Error; err != nil {
+			return nil, fmt.Errorf("content type %s not found", req.ContentType)
+		}
+		var count int64
+		database.DB.Model(&models.ContentEntry{}).Where("id = ? AND content_type_id = ?", req.EntryID, contentType.ID).Count(&count)
+		if count == 0 {
+			return nil, fmt.Errorf("entry %d of %s not found", req.EntryID, req.ContentType)
+		}
+
+		actions = append(actions, models.ReleaseAction{ContentTypeUID: req.ContentType, EntryID: req.EntryID, Action: req.Action})
+	}
+	return actions, nil
+}
+
+// validateReleaseActions checks that the entries of a release still exist and that the
+// entries to publish are complete: required fields are set and plugin fields are valid.
+// Lifecycle hooks may still reject an entry when the release is executed.
+func validateReleaseActions(actions []models.ReleaseAction) []releaseProblem {
+	problems := []releaseProblem{}
+	if len(actions) == 0 {
+		return append(problems, releaseProblem{Error: "Release has no actions"})
+	}
+
+	contentTypes := make(map[string]*models.ContentType)
+	for _, action := range actions {
+		problem := releaseProblem{ActionID: action.ID, ContentType: action.ContentTypeUID, EntryID: action.EntryID}
+
+		contentType, ok := contentTypes[action.ContentTypeUID]
+		if !ok {
+			contentType = &models.ContentType{}
+			if err := database.DB.Where("uid = ?", action.ContentTypeUID).First(contentType).Error; err != nil {
+				contentType = nil
+			}
+			contentTypes[action.ContentTypeUID] = contentType
+		}
+		if contentType == nil {
+			problem.Error = "Content type not found"
+			problems = append(problems, problem)
+			continue
+		}
+
+		var entry models.ContentEntry
+		if err := database.DB.Where("id = ? AND content_type_id = ?", action.EntryID, contentType.ID).First(&entry).Error; err != nil {
+			problem.Error = "Entry not found"
+			problems = append(problems, problem)
+			continue
+		}
+		if action.Action != "publish" {
+			continue
+		}
+
+		fieldErrors := validatePluginFields(contentType.Schema, entry.Data)
+		for name, message := range missingRequiredFields(contentType, &entry) {
+			if fieldErrors == nil {
+				fieldErrors = make(map[string]string)
+			}
+			fieldErrors[name] = message
+		}
+		if len(fieldErrors) > 0 {
+			problem.Error = "Entry is not publishable"
+			problem.Fields = fieldErrors
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// missingRequiredFields returns the required fields of the schema an entry has no value for
+func missingRequiredFields(contentType *models.ContentType, entry *models.ContentEntry) map[string]string {
+	var missing map[string]string
+	for name, definition := range contentType.Schema {
+		fieldMap, ok := definition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if required, _ := fieldMap["required"].(bool); !required {
+			continue
+		}
+
+		present := false
+		if field, ok := relationField(contentType.Schema, name); ok {
+			var count int64
+			query := database.DB.Model(&models.ContentRelation{})
+			if field.MappedBy != "" {
+				query = query.Where("target_content_type_uid = ? AND target_entry_id = ? AND source_field_name = ?",
+					contentType.UID, entry.ID, field.MappedBy)
+			} else {
+				query = query.Where("source_content_type_uid = ? AND source_entry_id = ? AND source_field_name = ?",
+					contentType.UID, entry.ID, name)
+			}
+			query.Count(&count)
+			present = count > 0
+		} else {
+			switch value := entry.Data[name].(type) {
+			case nil:
+			case string:
+				present = value != ""
+			case []interface{}:
+				present = len(value) > 0
+			default:
+				present = true
+			}
+		}
+
+		if !present {
+			if missing == nil {
+				missing = make(map[string]string)
+			}
+			missing[name] = "required"
+		}
+	}
+	return missing
+}
+
+// executeRelease validates a release and applies all its actions in one transaction.
+// Any failure, including a lifecycle hook rejecting an entry, leaves every entry unchanged.
+func executeRelease(c writeContext, release *models.Release, scheduled bool) error {
+	if problems := validateReleaseActions(release.Actions); len(problems) > 0 {
+		return &releaseValidationError{Problems: problems}
+	}
+
+	userID := entryWriterID(c)
+
+	var changed []models.ReleaseAction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Claiming the release first keeps concurrent requests and the scheduler from executing it twice
+		now := time.Now()
+		claim := tx.Model(&models.Release{}).
+			Where("id = ? AND status IN ?", release.ID, []string{models.ReleasePending, models.ReleaseFailed}).
+			Updates(map[string]interface{}{"status": models.ReleaseDone, "error": "", "executed_at": now, "executed_by_id": userID})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return errReleaseNotExecutable
+		}
+
+		note := fmt.Sprintf("Release %q", release.Name)
+		for i := range release.Actions {
+			action := &release.Actions[i]
+			status := "draft"
+			if action.Action == "publish" {
+				status = "published"
+			}
+
+			entryChanged, previous, err := setReleaseEntryStatus(tx, c, action, status, nil, false, note)
+			if err != nil {
+				return err
+			}
+			action.PreviousStatus = previous.Status
+			action.PreviousPublishedAt = previous.PublishedAt
+			if err := tx.Model(action).Select("previous_status", "previous_published_at").Updates(action).Error; err != nil {
+				return err
+			}
+			if entryChanged {
+				changed = append(changed, *action)
+			}
+		}
+
+		return createAuditLog(tx, c, "execute", "release", &release.ID, "Executed release", map[string]interface{}{
+			"name":      release.Name,
+			"actions":   len(release.Actions),
+			"changed":   len(changed),
+			"scheduled": scheduled,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	finishReleaseWrite(c, changed)
+	return nil
+}
+
+// revertRelease restores the status and publish date each entry had before the release
+func revertRelease(c *gin.Context, release *models.Release) error {
+	userID := entryWriterID(c)
+
+	var changed []models.ReleaseAction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		claim := tx.Model(&models.Release{}).
+			Where("id = ? AND status = ?", release.ID, models.ReleaseDone).
+			Updates(map[string]interface{}{"status": models.ReleaseReverted, "reverted_at": time.Now(), "reverted_by_id": userID})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return errReleaseNotExecutable
+		}
+
+		note := fmt.Sprintf("Reverted release %q", release.Name)
+		for i := range release.Actions {
+			action := &release.Actions[i]
+			if action.PreviousStatus == "" {
+				continue
+			}
+			entryChanged, _, err := setReleaseEntryStatus(tx, c, action, action.PreviousStatus, action.PreviousPublishedAt, true, note)
+			if err != nil {
+				return err
+			}
+			if entryChanged {
+				changed = append(changed, *action)
+			}
+		}
+
+		return createAuditLog(tx, c, "revert", "release", &release.ID, "Reverted release", map[string]interface{}{
+			"name":    release.Name,
+			"actions": len(release.Actions),
+			"changed": len(changed),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	finishReleaseWrite(c, changed)
+	return nil
+}
+
+// setReleaseEntryStatus changes the status of the entry of a release action like an entry
+// update does: lifecycle hooks, history and events. With restore set, publishedAt is set
+// as given, otherwise it is only set when the entry is published for the first time.
+// Entries that are missing or already have the status are left alone.
+func setReleaseEntryStatus(tx *gorm.DB, c writeContext, action *models.ReleaseAction, status string, publishedAt *time.Time, restore bool, note string) (bool, models.ContentEntry, error) {
+	var contentType models.ContentType
+	if err := tx.Where("uid = ?", action.ContentTypeUID).First(&contentType).Error; err != nil {
+		if restore {
+			return false, models.ContentEntry{}, nil
+		}
+		return false, models.ContentEntry{}, err
+	}
+
+	var entry models.ContentEntry
+	if err := tx.Where("id = ? AND content_type_id = ?", action.EntryID, contentType.ID).First(&entry).Error; err != nil {
+		if restore {
+			return false, models.ContentEntry{}, nil
+		}
+		return false, models.ContentEntry{}, err
+	}
+	previous := entry
+	if entry.Status == status {
+		return false, previous, nil
+	}
+
+	// Hooks and slug fields change the data in place, previous keeps the stored one
+	entry.Data = make(models.JSONB, len(previous.Data))
+	for k, v := range previous.Data {
+		entry.Data[k] = v
+	}
+
+	entry.Status = status
+	if restore {
+		entry.PublishedAt = publishedAt
+	} else if status == "published" && entry.PublishedAt == nil {
+		now := time.Now()
+		entry.PublishedAt = &now
+	}
+	entry.UpdatedByID = entryWriterID(c)
+
+	if err := runBeforeWriteHooks(tx, c, lifecycle.BeforeUpdateAction, &contentType, &entry, &previous); err != nil {
+		return false, previous, err
+	}
+	if entry.Status == "published" && entry.PublishedAt == nil {
+		now := time.Now()
+		entry.PublishedAt = &now
+	}
+	if err := tx.Save(&entry).Error; err != nil {
+		return false, previous, err
+	}
+
+	// Hooks may have changed the slug
+	if err := recordSlugChanges(tx, &contentType, &entry, previous.Data, entry.UpdatedByID); err != nil {
+		return false, previous, err
+	}
+
+	changeType := "unpublished"
+	if entry.Status == "published" {
+		changeType = "published"
+	}
+	if err := createContentHistory(tx, entry.ID, changeType, note, entry.Data, entry.UpdatedByID); err != nil {
+		return false, previous, err
+	}
+
+	if err := runLifecycleHooks(tx, c, lifecycle.AfterUpdateAction, &contentType, &entry, &previous); err != nil {
+		return false, previous, err
+	}
+
+	events := append([]string{webhooks.EntryUpdate}, statusWebhookEvents(previous.Status, entry.Status)...)
+	return true, previous, queueEntryEvents(tx, c, &contentType, &entry, events...)
+}
+
+// finishReleaseWrite clears cached responses of the changed entries and publishes their events
+func finishReleaseWrite(c writeContext, changed []models.ReleaseAction) {
+	for _, action := range changed {
+		invalidateEntryCache(action.ContentTypeUID, action.EntryID)
+	}
+	notifyEntryEvents(c)
+}
+
+var releaseSchedulerOnce sync.Once
+
+// StartReleaseScheduler executes pending releases once their scheduled time has come
+func StartReleaseScheduler() {
+	releaseSchedulerOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(releasePollInterval)
+			defer ticker.Stop()
+			for {
+				executeDueReleases()
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+func executeDueReleases() {
+	var due []models.Release
+	if err := database.DB.Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", models.ReleasePending, time.Now()).
+		Order("scheduled_at ASC").Find(&due).Error; err != nil {
+		log.Printf("Release scheduler: %v", err)
+		return
+	}
+
+	for i := range due {
+		release, err := loadRelease(due[i].ID)
+		if err != nil {
+			continue
+		}
+
+		// Changes are made on behalf of the user who created the release
+		err = executeRelease(newBackgroundWrite(release.CreatedByID, "xivercms-release-scheduler"), release, true)
+		if err == nil || errors.Is(err, errReleaseNotExecutable) {
+			continue
+		}
+
+		log.Printf("Release %d (%s) failed: %v", release.ID, release.Name, err)
+		message := err.Error()
+		var invalid *releaseValidationError
+		if errors.As(err, &invalid) {
+			details, _ := json.Marshal(invalid.Problems)
+			message = err.Error() + ": " + string(details)
+		}
+		database.DB.Model(&models.Release{}).Where("id = ? AND status = ?", release.ID, models.ReleasePending).
+			Updates(map[string]interface{}{"status": models.ReleaseFailed, "error": message})
+		notifyReleaseFailed(release, message)
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// sitemapMaxURLs is the limit of URLs per sitemap file set by the sitemaps protocol
+	sitemapMaxURLs = 50000
+	sitemapNS      = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+// sitemapURL is the path of a published entry on the site
+type sitemapURL struct {
+	Path    string
+	LastMod time.Time
+}
+
+// sitemapTypeURLs are the URLs of one content type, built when first needed
+type sitemapTypeURLs struct {
+	urls    []sitemapURL
+	builtAt time.Time
+}
+
+// sitemapBuild is a running build of the URLs of a content type. Concurrent requests
+// wait for it instead of scanning the same entries again.
+type sitemapBuild struct {
+	done    chan struct{}
+	urls    *sitemapTypeURLs
+	err     error
+	version uint64
+}
+
+// sitemapCache holds the URLs per content type UID. invalidateSitemap drops a content type
+// when its published entries change, so only that type is reloaded on the next request.
+// The cache lives in the process: with several instances, each one only sees the
+// invalidations of its own writes, and other instances serve their copy until they
+// rebuild it.
+var sitemapCache = struct {
+	sync.Mutex
+	types    map[string]*sitemapTypeURLs
+	building map[string]*sitemapBuild
+	versions map[string]uint64 // Bumped by invalidateSitemap, a build started before is not stored
+}{
+	types:    make(map[string]*sitemapTypeURLs),
+	building: make(map[string]*sitemapBuild),
+	versions: make(map[string]uint64),
+}
+
+// invalidateSitemap makes the next sitemap request reload the URLs of a content type
+func invalidateSitemap(contentTypeUID string) {
+	sitemapCache.Lock()
+	delete(sitemapCache.types, contentTypeUID)
+	delete(sitemapCache.building, contentTypeUID)
+	sitemapCache.versions[contentTypeUID]++
+	sitemapCache.Unlock()
+}
+
+// currentSitemap returns the URLs of all content types in the sitemap, ordered by content
+// type UID, and a validator of their version
+func currentSitemap() ([]sitemapURL, string, error) {
+	// Only public content types are listed, crawlers are anonymous
+	var contentTypes []models.ContentType
+	if err := database.DB.Where("in_sitemap = ? AND is_visible = ? AND access_type = ? AND url_pattern <> ?", true, true, "public", "").
+		Order("uid ASC").Find(&contentTypes).Error; err != nil {
+		return nil, "", err
+	}
+
+	hash := sha1.New()
+	seen := make(map[string]bool)
+	var urls []sitemapURL
+	for i := range contentTypes {
+		contentType := &contentTypes[i]
+		typeURLs, err := sitemapTypeURLsOf(contentType)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Fprintf(hash, "%s|%d|%d|", contentType.UID, contentType.UpdatedAt.UnixNano(), typeURLs.builtAt.UnixNano())
+
+		for _, u := range typeURLs.urls {
+			if !seen[u.Path] {
+				seen[u.Path] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+
+	return urls, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sitemapTypeURLsOf returns the cached URLs of a content type. A missing type is built
+// outside the lock, once: concurrent requests for the same type wait for that build,
+// requests for other types are not blocked.
+func sitemapTypeURLsOf(contentType *models.ContentType) (*sitemapTypeURLs, error) {
+	sitemapCache.Lock()
+	if typeURLs, ok := sitemapCache.types[contentType.UID]; ok {
+		sitemapCache.Unlock()
+		return typeURLs, nil
+	}
+	if build, ok := sitemapCache.building[contentType.UID]; ok {
+		sitemapCache.Unlock()
+		<-build.done
+		return build.urls, build.err
+	}
+	build := &sitemapBuild{done: make(chan struct{}), version: sitemapCache.versions[contentType.UID]}
+	sitemapCache.building[contentType.UID] = build
+	sitemapCache.Unlock()
+
+	defer close(build.done)
+	built, err := buildSitemapURLs(contentType)
+	build.urls, build.err = &sitemapTypeURLs{urls: built, builtAt: time.Now()}, err
+
+	sitemapCache.Lock()
+	if sitemapCache.building[contentType.UID] == build {
+		delete(sitemapCache.building, contentType.UID)
+	}
+	if err == nil && sitemapCache.versions[contentType.UID] == build.version {
+		sitemapCache.types[contentType.UID] = build.urls
+	}
+	sitemapCache.Unlock()
+
+	return build.urls, build.err
+}
+
+// buildSitemapURLs renders the URL pattern of every published entry of a content type
+// that anonymous clients may read. Entries missing a value of the pattern are left out.
+func buildSitemapURLs(contentType *models.ContentType) ([]sitemapURL, error) {
+	var urls []sitemapURL
+	var batch []models.ContentEntry
+	query := database.DB.Model(&models.ContentEntry{}).Where("content_type_id = ? AND status = ?", contentType.ID, "published")
+	err := anonymousEntryViewer().scope(query).Select("id", "data", "updated_at").
+		Order("id ASC").
+		FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				if path := renderURLPattern(contentType.URLPattern, &batch[i]); path != "" {
+					urls = append(urls, sitemapURL{Path: path, LastMod: batch[i].UpdatedAt})
+				}
+			}
+			return nil
+		}).Error
+	return urls, err
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name          `xml:"urlset"`
+	NS      string            `xml:"xmlns,attr"`
+	URLs    []sitemapURLEntry `xml:"url"`
+}
+
+type sitemapURLEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name              `xml:"sitemapindex"`
+	NS       string                `xml:"xmlns,attr"`
+	Sitemaps []sitemapIndexSitemap `xml:"sitemap"`
+}
+
+type sitemapIndexSitemap struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Sitemap serves /sitemap.xml: the URLs of published entries, or an index of sitemap
+// pages once there are more than 50,000 URLs
+func Sitemap(c *gin.Context) {
+	urls, version, err := currentSitemap()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(urls) <= sitemapMaxURLs {
+		writeSitemapURLSet(c, urls, version)
+		return
+	}
+
+	index := sitemapIndex{NS: sitemapNS}
+	base := requestBaseURL(c)
+	for page := 1; (page-1)*sitemapMaxURLs < len(urls); page++ {
+		pageURLs := sitemapPage(urls, page)
+		index.Sitemaps = append(index.Sitemaps, sitemapIndexSitemap{
+			Loc:     base + "/sitemaps/" + strconv.Itoa(page) + ".xml",
+			LastMod: sitemapLastMod(pageURLs).UTC().Format(time.RFC3339),
+		})
+	}
+	writeSitemapXML(c, index, version, sitemapLastMod(urls))
+}
+
+// SitemapPage serves /sitemaps/{page}.xml, a part of a sitemap index
+func SitemapPage(c *gin.Context) {
+	page, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+	if err != nil || page < 1 || !strings.HasSuffix(c.Param("page"), ".xml") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+
+	urls, version, err := currentSitemap()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pageURLs := sitemapPage(urls, page)
+	if len(pageURLs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+	writeSitemapURLSet(c, pageURLs, version+"|"+strconv.Itoa(page))
+}
+
+func sitemapPage(urls []sitemapURL, page int) []sitemapURL {
+	start := (page - 1) * sitemapMaxURLs
+	if start >= len(urls) {
+		return nil
+	}
+	end := start + sitemapMaxURLs
+	if end > len(urls) {
+		end = len(urls)
+	}
+	return urls[start:end]
+}
+
+func sitemapLastMod(urls []sitemapURL) time.Time {
+	var lastMod time.Time
+	for _, u := range urls {
+		if u.LastMod.After(lastMod) {
+			lastMod = u.LastMod
+		}
+	}
+	return lastMod
+}
+
+func writeSitemapURLSet(c *gin.Context, urls []sitemapURL, version string) {
+	set := sitemapURLSet{NS: sitemapNS, URLs: make([]sitemapURLEntry, len(urls))}
+	base := siteBaseURL(c)
+	for i, u := range urls {
+		set.URLs[i] = sitemapURLEntry{Loc: base + u.Path, LastMod: u.LastMod.UTC().Format(time.RFC3339)}
+	}
+	writeSitemapXML(c, set, version, sitemapLastMod(urls))
+}
+
+// writeSitemapXML writes a sitemap document with validators, answering 304 when the
+// client's copy is current
+func writeSitemapXML(c *gin.Context, document interface{}, version string, lastModified time.Time) {
+	// URLs depend on the host the sitemap was requested from unless SITE_URL is set
+	sum := sha1.Sum([]byte(version + "|" + siteBaseURL(c) + "|" + requestBaseURL(c)))
+	etag := `W/"` + hex.EncodeToString(sum[:]) + `"`
+
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", "public, no-cache")
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && cache.ETagMatches(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	body, err := xml.Marshal(document)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
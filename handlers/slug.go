@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+var (
+	errSlugTaken   = errors.New("slug is already used by another entry")
+	errInvalidSlug = errors.New("invalid slug")
+)
+
+// cyrillicTranslit maps Cyrillic letters to their Latin transliteration
+var cyrillicTranslit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+	// Ukrainian and Belarusian
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// slugify converts text into a lowercase URL-safe slug, transliterating Cyrillic
+func slugify(text string) string {
+	var b strings.Builder
+	pendingDash := false
+
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if pendingDash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingDash = false
+		b.WriteString(s)
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			write(string(r))
+		case cyrillicTranslit[r] != "":
+			write(cyrillicTranslit[r])
+		case r == 'ъ' || r == 'ь' || r == '\'':
+			// Signs and apostrophes are dropped without splitting the word
+		default:
+			pendingDash = true
+		}
+	}
+
+	return b.String()
+}
+
+// slugFieldNames returns the schema fields of type uid/slug
+func slugFieldNames(schema models.JSONB) []string {
+	var names []string
+	for name, def := range schema {
+		if fieldMap, ok := def.(map[string]interface{}); ok {
+			if fieldType, _ := fieldMap["type"].(string); fieldType == "uid" || fieldType == "slug" {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applySlugFields fills the slug fields of data and keeps them unique within the content type.
+// input is the data sent by the client: explicit slugs are normalized and must be free,
+// missing or empty slugs are generated from the field's targetField with a numeric suffix on collision.
+// entryID is 0 for new entries.
+func applySlugFields(db *gorm.DB, contentType *models.ContentType, entryID uint, data, input map[string]interface{}) error {
+	for _, fieldName := range slugFieldNames(contentType.Schema) {
+		fieldMap := contentType.Schema[fieldName].(map[string]interface{})
+
+		if value, ok := input[fieldName].(string); ok && value != "" {
+			slug := slugify(value)
+			if slug == "" {
+				return fmt.Errorf("field %s: %w %q", fieldName, errInvalidSlug, value)
+			}
+			if slugTaken(db, contentType.ID, entryID, fieldName, slug) {
+				return fmt.Errorf("field %s: %w", fieldName, errSlugTaken)
+			}
+			data[fieldName] = slug
+			continue
+		}
+
+		// Keep an existing slug unless the client cleared it
+		if _, sent := input[fieldName]; !sent {
+			if current, ok := data[fieldName].(string); ok && current != "" {
+				continue
+			}
+		}
+
+		targetField, _ := fieldMap["targetField"].(string)
+		source, _ := data[targetField].(string)
+		base := slugify(source)
+		if base == "" {
+			delete(data, fieldName)
+			continue
+		}
+
+		slug := base
+		for i := 1; slugTaken(db, contentType.ID, entryID, fieldName, slug); i++ {
+			slug = base + "-" + strconv.Itoa(i)
+		}
+		data[fieldName] = slug
+	}
+
+	return nil
+}
+
+// validateSlugSchema checks the uid fields of a content type being saved. Their values
+// are compared inside the JSON data column, which only works for plain key names.
+func validateSlugSchema(contentType *models.ContentType) error {
+	for _, fieldName := range slugFieldNames(contentType.Schema) {
+		if !database.IsValidJSONKey(fieldName) {
+			return fmt.Errorf("uid field %s: name may only contain letters, digits and underscores and must not start with a digit", fieldName)
+		}
+	}
+	return nil
+}
+
+// slugErrorStatus maps an applySlugFields error to an HTTP status
+func slugErrorStatus(err error) int {
+	if errors.Is(err, errSlugTaken) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// whereSlug restricts query to entries whose slug fields match slug.
+// Returns nil when the schema has no slug fields.
+func whereSlug(query *gorm.DB, schema models.JSONB, slug string) *gorm.DB {
+	fields := slugFieldNames(schema)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	conditions := database.DB.Where(database.JSONField("data", fields[0])+" = ?", slug)
+	for _, fieldName := range fields[1:] {
+		conditions = conditions.Or(database.JSONField("data", fieldName)+" = ?", slug)
+	}
+	return query.Where(conditions)
+}
+
+func slugTaken(db *gorm.DB, contentTypeID, entryID uint, fieldName, slug string) bool {
+	var count int64
+	db.Model(&models.ContentEntry{}).
+		Where("content_type_id = ? AND id <> ?", contentTypeID, entryID).
+		Where(database.JSONField("data", fieldName)+" = ?", slug).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/xivercms/xivercms/config"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/realtime"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+const (
+	// streamHeartbeat is how often an idle stream sends a heartbeat. Access to content
+	// types is checked again after every heartbeat.
+	streamHeartbeat = 25 * time.Second
+	// streamWriteTimeout limits a WebSocket write to a stalled client
+	streamWriteTimeout = 10 * time.Second
+	// streamEventsKey holds the change feed events of a write until it is committed
+	streamEventsKey = "streamEvents"
+)
+
+// StreamEntries pushes changes of published entries as Server-Sent Events.
+// URL: /api/stream?contentTypes=articles,news
+func StreamEntries(c *gin.Context) {
+	stream, ok := openEntryStream(c, c.GetHeader("Last-Event-ID"))
+	if !ok {
+		return
+	}
+	defer stream.sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	if !stream.resumed {
+		// The ID moves the client's resume point to now
+		writeSSE(c, "reset", stream.sub.LastID, gin.H{"lastEventId": stream.sub.LastID})
+	}
+	for _, event := range stream.replay {
+		if stream.allowed(c, event) {
+			writeSSE(c, event.Type, event.ID, event)
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, open := <-stream.sub.C:
+			if !open {
+				// Fell behind, the client reconnects and resumes from its last event
+				return
+			}
+			if stream.allowed(c, event) {
+				writeSSE(c, event.Type, event.ID, event)
+				c.Writer.Flush()
+			}
+		case <-heartbeat.C:
+			if !stream.refresh(c) {
+				return
+			}
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeSSE(c *gin.Context, eventType string, id uint64, data interface{}) {
+	payload, _ := json.Marshal(data)
+	if id > 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", eventType, payload)
+}
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     streamOriginAllowed,
+}
+
+// streamOriginAllowed accepts WebSocket connections from the CORS origins and from
+// clients that send no Origin (servers, scripts)
+func streamOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range config.AppConfig.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamEntriesWebSocket pushes the same events as StreamEntries as JSON WebSocket messages.
+// URL: /api/stream/ws?contentTypes=articles,news&lastEventId=123
+func StreamEntriesWebSocket(c *gin.Context) {
+	stream, ok := openEntryStream(c, c.Query("lastEventId"))
+	if !ok {
+		return
+	}
+	defer stream.sub.Close()
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has responded
+		return
+	}
+	defer conn.Close()
+
+	// Messages from the client are not expected; reading detects the close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteJSON(message) == nil
+	}
+
+	if !stream.resumed && !send(gin.H{"type": "reset", "lastEventId": stream.sub.LastID}) {
+		return
+	}
+	for _, event := range stream.replay {
+		if stream.allowed(c, event) && !send(event) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, open := <-stream.sub.C:
+			if !open {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow"),
+					time.Now().Add(streamWriteTimeout))
+				return
+			}
+			if stream.allowed(c, event) && !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if !stream.refresh(c) {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token expired"),
+					time.Now().Add(streamWriteTimeout))
+				return
+			}
+			if !send(gin.H{"type": "heartbeat", "time": time.Now().UTC()}) {
+				return
+			}
+		}
+	}
+}
+
+// entryStream is a change feed connection
+type entryStream struct {
+	sub     *realtime.Subscription
+	replay  []realtime.Event
+	resumed bool // false if the client asked to resume but missed events
+
+	contentTypes map[string]bool // Requested content types, nil for all
+	access       map[string]bool // Cached access checks, cleared on every heartbeat
+}
+
+// openEntryStream checks the requested content types and subscribes to the broker,
+// responding with an error if the stream cannot be opened
+func openEntryStream(c *gin.Context, lastEventID string) (*entryStream, bool) {
+	stream := &entryStream{resumed: true, access: make(map[string]bool)}
+
+	if uids := splitList(c.Query("contentTypes")); len(uids) > 0 {
+		stream.contentTypes = make(map[string]bool, len(uids))
+		for _, uid := range uids {
+			var contentType models.ContentType
+			if err := database.DB.Where("uid = ? AND is_visible = ?", uid, true).First(&contentType).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Content type %s not found", uid)})
+				return nil, false
+			}
+			if !middleware.CheckContentTypeAccess(uid, c) {
+				c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Access denied to %s", uid)})
+				return nil, false
+			}
+			stream.contentTypes[uid] = true
+		}
+	}
+
+	var resumeFrom uint64
+	resume := lastEventID != ""
+	if resume {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid last event ID"})
+			return nil, false
+		}
+		resumeFrom = id
+	}
+
+	stream.sub, stream.replay, stream.resumed = realtime.Default.Subscribe(resumeFrom, resume)
+	return stream, true
+}
+
+// allowed reports whether the connected identity may receive an event: events of its
+// content types, and of restricted entries only if they are in the entry's access list.
+// Removals carry no entry data and reach every subscriber of the content type.
+func (s *entryStream) allowed(c *gin.Context, event realtime.Event) bool {
+	uid := event.ContentType
+	if s.contentTypes != nil && !s.contentTypes[uid] {
+		return false
+	}
+	if event.Restricted && event.Entry != nil && !currentEntryViewer(c).allowsID(event.EntryID) {
+		return false
+	}
+
+	allowed, checked := s.access[uid]
+	if !checked {
+		var contentType models.ContentType
+		allowed = database.DB.Where("uid = ? AND is_visible = ?", uid, true).First(&contentType).Error == nil &&
+			middleware.CheckContentTypeAccess(uid, c)
+		s.access[uid] = allowed
+	}
+	return allowed
+}
+
+// refresh forgets the cached access checks and reports whether the stream may go on,
+// which it may not once the JWT it was opened with has expired
+func (s *entryStream) refresh(c *gin.Context) bool {
+	s.access = make(map[string]bool)
+	c.Set(entryViewerKey, nil)
+	expiresAt, ok := c.Get("tokenExpiresAt")
+	return !ok || time.Now().Before(expiresAt.(time.Time))
+}
+
+// queueEntryEvents queues the webhooks and notifications of an entry change inside its
+// transaction and records its change feed event, published by notifyEntryEvents after the commit
+func queueEntryEvents(tx *gorm.DB, c writeContext, contentType *models.ContentType, entry *models.ContentEntry, events ...string) error {
+	if err := queueEntryWebhooks(tx, contentType.UID, entry, events...); err != nil {
+		return err
+	}
+	if err := queueEntryStatusNotifications(tx, c, contentType, entry, events); err != nil {
+		return err
+	}
+
+	eventType := streamEventType(entry.Status, events)
+	if eventType == "" {
+		return nil
+	}
+	event := realtime.Event{Type: eventType, ContentType: contentType.UID, EntryID: entry.ID, Restricted: entry.Restricted}
+	if eventType != realtime.EntryUnpublish && eventType != realtime.EntryDelete {
+		published := *entry
+		published.Data = serializePluginFields(contentType.Schema, entry.Data)
+		event.Entry = publicEntryMap(&published)
+	}
+
+	pending, _ := c.Get(streamEventsKey)
+	queued, _ := pending.([]realtime.Event)
+	c.Set(streamEventsKey, append(queued, event))
+	return nil
+}
+
+// notifyEntryEvents wakes the webhook worker, publishes the change feed events of a
+// committed write and delivers its notifications. The same events are the changes of
+// published entries the sitemap follows.
+func notifyEntryEvents(c writeContext) {
+	webhooks.Notify()
+	deliverNotifications(c)
+
+	pending, _ := c.Get(streamEventsKey)
+	if queued, _ := pending.([]realtime.Event); len(queued) > 0 {
+		c.Set(streamEventsKey, nil)
+		realtime.Default.Publish(queued...)
+		for _, event := range queued {
+			invalidateSitemap(event.ContentType)
+		}
+	}
+}
+
+// streamEventType maps the webhook events of a change to the change feed, which follows
+// published entries only: drafts are not streamed, unpublishing reads as a removal
+func streamEventType(status string, events []string) string {
+	has := func(event string) bool {
+		for _, e := range events {
+			if e == event {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has(webhooks.EntryDelete):
+		if status == "published" {
+			return realtime.EntryDelete
+		}
+	case has(webhooks.EntryCreate):
+		if status == "published" {
+			return realtime.EntryCreate
+		}
+	case has(webhooks.EntryPublish):
+		return realtime.EntryPublish
+	case has(webhooks.EntryUnpublish):
+		return realtime.EntryUnpublish
+	case has(webhooks.EntryUpdate):
+		if status == "published" {
+			return realtime.EntryUpdate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/lifecycle"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/submissions"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultSubmissionRateLimit is the number of submissions per IP address and hour
+	defaultSubmissionRateLimit = 10
+	maxSubmissionRateLimit     = 10000
+	// maxSubmissionBody limits the request body of a submission
+	maxSubmissionBody = 64 << 10
+)
+
+var (
+	submissionLimiter = submissions.NewLimiter(time.Hour)
+
+	emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+	urlPattern   = regexp.MustCompile(`^https?://.+`)
+)
+
+// submissionRejectedFieldTypes cannot be written by anonymous clients
+var submissionRejectedFieldTypes = map[string]bool{"relation": true, "media": true, "mediaMultiple": true, "password": true}
+
+type SubmitContentEntryRequest struct {
+	Data         map[string]interface{} `json:"data" binding:"required"`
+	CaptchaToken string                 `json:"captchaToken"`
+}
+
+// PublicSubmitContentEntry creates a draft entry from an anonymous submission, e.g. a
+// contact form. The content type must enable submissions.
+// URL: POST /api/{uid}
+func PublicSubmitContentEntry(c *gin.Context) {
+	contentTypeUID := c.Param("uid")
+	if isReservedRoute(contentTypeUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ? AND is_visible = ?", contentTypeUID, true).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+	settings := contentType.Submissions
+	if !settings.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Submissions are not enabled for this content type"})
+		return
+	}
+
+	// Every attempt counts, so that invalid submissions cannot be used to probe
+	limit := settings.RateLimit
+	if limit == 0 {
+		limit = defaultSubmissionRateLimit
+	}
+	if ok, retryAfter := submissionLimiter.Allow(contentTypeUID+"|"+c.ClientIP(), limit); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many submissions, try again later"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSubmissionBody)
+	var req SubmitContentEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Bots fill the hidden field; they get the usual answer so they do not adapt
+	if settings.Honeypot != "" {
+		if value, ok := req.Data[settings.Honeypot]; ok {
+			if value != nil && value != "" {
+				c.JSON(http.StatusCreated, gin.H{"message": "Submission received"})
+				return
+			}
+			delete(req.Data, settings.Honeypot)
+		}
+	}
+
+	if settings.Captcha {
+		verifier := submissions.CurrentVerifier()
+		if verifier == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "CAPTCHA verification is not configured"})
+			return
+		}
+		ok, err := verifier.Verify(c.Request.Context(), req.CaptchaToken, c.ClientIP())
+		if err != nil {
+			log.Printf("CAPTCHA verification failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "CAPTCHA verification is unavailable"})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CAPTCHA verification failed"})
+			return
+		}
+	}
+
+	if fieldErrors := validateSubmissionData(&contentType, req.Data); fieldErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fields", "fields": fieldErrors})
+		return
+	}
+
+	entryData := make(map[string]interface{}, len(req.Data))
+	for k, v := range req.Data {
+		entryData[k] = v
+	}
+	// Submissions are drafts until an editor publishes them
+	entry := models.ContentEntry{
+		ContentTypeID: contentType.ID,
+		Data:          models.JSONB(entryData),
+		Status:        "draft",
+	}
+	if userId, exists := c.Get("userId"); exists {
+		userID := userId.(uint)
+		entry.CreatedByID = &userID
+		entry.UpdatedByID = &userID
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applySlugFields(tx, &contentType, 0, entry.Data, req.Data); err != nil {
+			return err
+		}
+		if err := runBeforeWriteHooks(tx, c, lifecycle.BeforeCreateAction, &contentType, &entry, nil); err != nil {
+			return err
+		}
+		entry.Status = "draft"
+		entry.PublishedAt = nil
+
+		if contentType.IsTree {
+			entry.Position = nextTreePosition(tx, contentType.ID, nil)
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		if err := createAuditLog(tx, c, "submit", "content-entry", &entry.ID, "Submitted content entry", map[string]interface{}{
+			"contentType": contentTypeUID,
+		}); err != nil {
+			return err
+		}
+		if err := createContentHistory(tx, entry.ID, "created", "Entry submitted", entry.Data, entry.CreatedByID); err != nil {
+			return err
+		}
+
+		if err := runLifecycleHooks(tx, c, lifecycle.AfterCreateAction, &contentType, &entry, nil); err != nil {
+			return err
+		}
+		if err := queueSubmissionNotifications(tx, c, &contentType, &entry); err != nil {
+			return err
+		}
+
+		return queueEntryEvents(tx, c, &contentType, &entry, webhooks.EntryCreate, webhooks.EntrySubmit)
+	})
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+
+	invalidateEntryCache(contentTypeUID, entry.ID)
+	notifyEntryEvents(c)
+	submissions.Notify(submissions.Submission{
+		ContentType: contentTypeUID,
+		EntryID:     entry.ID,
+		Data:        entry.Data,
+		IP:          c.ClientIP(),
+		CreatedAt:   entry.CreatedAt,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Submission received"})
+}
+
+// validateSubmissionData checks a submission against the writable fields and the schema:
+// required fields, value types, length and range limits, enum options and plugin field types
+func validateSubmissionData(contentType *models.ContentType, data map[string]interface{}) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	writable := make(map[string]bool, len(contentType.Submissions.Fields))
+	for _, name := range contentType.Submissions.Fields {
+		writable[name] = true
+	}
+	for name := range data {
+		if !writable[name] {
+			fieldErrors[name] = "field is not writable"
+		}
+	}
+
+	for _, name := range contentType.Submissions.Fields {
+		fieldMap, ok := contentType.Schema[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, present := data[name]
+		if !present || value == nil || value == "" {
+			if required, _ := fieldMap["required"].(bool); required {
+				fieldErrors[name] = "required"
+			}
+			continue
+		}
+		if err := validateFieldValue(fieldMap, value); err != nil {
+			fieldErrors[name] = err.Error()
+		}
+	}
+
+	for name, message := range validatePluginFields(contentType.Schema, data) {
+		if _, exists := fieldErrors[name]; !exists {
+			fieldErrors[name] = message
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return fieldErrors
+}
+
+// validateFieldValue checks a value against the type and limits of its schema definition.
+// Field types without rules of their own, e.g. json, accept any value.
+func validateFieldValue(fieldMap map[string]interface{}, value interface{}) error {
+	fieldType, _ := fieldMap["type"].(string)
+
+	switch fieldType {
+	case "string", "text", "richtext", "email", "url", "uid":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		length := len([]rune(text))
+		if minLength, ok := fieldMap["minLength"].(float64); ok && float64(length) < minLength {
+			return fmt.Errorf("minimum length: %v", minLength)
+		}
+		if maxLength, ok := fieldMap["maxLength"].(float64); ok && float64(length) > maxLength {
+			return fmt.Errorf("maximum length: %v", maxLength)
+		}
+		if fieldType == "email" && !emailPattern.MatchString(text) {
+			return fmt.Errorf("invalid email format")
+		}
+		if fieldType == "url" && !urlPattern.MatchString(text) {
+			return fmt.Errorf("invalid URL format")
+		}
+
+	case "number", "integer", "float", "decimal":
+		number, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("must be a number")
+		}
+		if fieldType == "integer" && number != math.Trunc(number) {
+			return fmt.Errorf("must be an integer")
+		}
+		if min, ok := fieldMap["min"].(float64); ok && number < min {
+			return fmt.Errorf("minimum value: %v", min)
+		}
+		if max, ok := fieldMap["max"].(float64); ok && number > max {
+			return fmt.Errorf("maximum value: %v", max)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+
+	case "date", "datetime", "time":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		layouts := map[string][]string{
+			"date":     {"2006-01-02"},
+			"datetime": {time.RFC3339, "2006-01-02T15:04"},
+			"time":     {"15:04", "15:04:05"},
+		}[fieldType]
+		valid := false
+		for _, layout := range layouts {
+			if _, err := time.Parse(layout, text); err == nil {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid %s format", fieldType)
+		}
+
+	case "enum", "enumeration":
+		options, _ := fieldMap["options"].([]interface{})
+		for _, option := range options {
+			if option == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of the options")
+	}
+	return nil
+}
+
+// validateSubmissionConfig checks the submission settings of a content type
+func validateSubmissionConfig(contentType *models.ContentType) error {
+	settings := contentType.Submissions
+	if settings.RateLimit < 0 || settings.RateLimit > maxSubmissionRateLimit {
+		return fmt.Errorf("submission rate limit must be between 0 and %d", maxSubmissionRateLimit)
+	}
+	if settings.Enabled && len(settings.Fields) == 0 {
+		return fmt.Errorf("submissions require at least one writable field")
+	}
+
+	for _, name := range settings.Fields {
+		fieldMap, ok := contentType.Schema[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("submission field %s is not in the schema", name)
+		}
+		if fieldType, _ := fieldMap["type"].(string); submissionRejectedFieldTypes[fieldType] {
+			return fmt.Errorf("submission field %s cannot be of type %s", name, fieldType)
+		}
+	}
+
+	if settings.Honeypot != "" {
+		if _, inSchema := contentType.Schema[settings.Honeypot]; inSchema {
+			return fmt.Errorf("honeypot field %s must not be in the schema", settings.Honeypot)
+		}
+	}
+	if settings.Enabled && settings.Captcha && submissions.CurrentVerifier() == nil {
+		return fmt.Errorf("submissions require CAPTCHA but CAPTCHA_PROVIDER is not configured")
+	}
+	return nil
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/xivercms/xivercms/cache"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/lifecycle"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+// maxTreeDepth guards ancestor walks against corrupted parent chains
+const maxTreeDepth = 100
+
+var errTreeCycle = errors.New("entry cannot be moved under itself or one of its descendants")
+
+// GetContentTree returns all entries of a tree-enabled content type as a nested tree
+func GetContentTree(c *gin.Context) {
+	contentTypeUID := c.Param("uid")
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	if !contentType.IsTree {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content type is not tree-enabled"})
+		return
+	}
+
+	query := database.DB.Where("content_type_id = ?", contentType.ID)
+
+	// Filter by status (unmatched nodes hide their subtree)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var entries []models.ContentEntry
+	if err := query.Order("position ASC, id ASC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": buildEntryTree(entries, adminTreeEntryMap),
+		"meta": gin.H{
+			"total": len(entries),
+		},
+	})
+}
+
+// GetContentEntryPath returns the breadcrumb path from the root to an entry
+func GetContentEntryPath(c *gin.Context) {
+	contentTypeUID := c.Param("uid")
+	entryID := c.Param("id")
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	var entry models.ContentEntry
+	if err := database.DB.Where("id = ? AND content_type_id = ?", entryID, contentType.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+
+	path, err := entryAncestors(database.DB, &entry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	path = append(path, entry)
+
+	result := make([]map[string]interface{}, len(path))
+	for i := range path {
+		result[i] = adminTreeEntryMap(&path[i])
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type MoveContentEntryRequest struct {
+	ParentID *uint `json:"parentId"` // null moves the entry to the root level, omitted keeps the parent
+	Position *int  `json:"position"` // Optional, appends to the end when omitted
+}
+
+// MoveContentEntry changes the parent and/or sibling position of an entry
+func MoveContentEntry(c *gin.Context) {
+	contentTypeUID := c.Param("uid")
+	entryID := c.Param("id")
+
+	var contentType models.ContentType
+	if err := database.DB.Where("uid = ?", contentTypeUID).First(&contentType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content type not found"})
+		return
+	}
+
+	if !contentType.IsTree {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content type is not tree-enabled"})
+		return
+	}
+
+	var entry models.ContentEntry
+	if err := database.DB.Where("id = ? AND content_type_id = ?", entryID, contentType.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+
+	var req MoveContentEntryRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Omitted parentId keeps the current parent (reorder only), explicit null moves to the root
+	var fields map[string]interface{}
+	c.ShouldBindBodyWith(&fields, binding.JSON)
+	if _, ok := fields["parentId"]; !ok {
+		req.ParentID = entry.ParentID
+	}
+
+	if err := validateTreeParent(database.DB, &contentType, entry.ID, req.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := entry
+	previousParentID := entry.ParentID
+	entry.ParentID = req.ParentID
+
+	userId, _ := c.Get("userId")
+	userID := userId.(uint)
+	entry.UpdatedByID = &userID
+
+	// Positions of both sibling lists, audit log and history are written together.
+	// A move is an update, so the update hooks run as well.
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := runBeforeWriteHooks(tx, c, lifecycle.BeforeUpdateAction, &contentType, &entry, &previous); err != nil {
+			return err
+		}
+		if entry.Status == "published" && entry.PublishedAt == nil {
+			now := time.Now()
+			entry.PublishedAt = &now
+		}
+
+		if err := tx.Save(&entry).Error; err != nil {
+			return err
+		}
+
+		// Hooks may have changed the slug
+		if err := recordSlugChanges(tx, &contentType, &entry, previous.Data, &userID); err != nil {
+			return err
+		}
+
+		if err := placeInSiblings(tx, &entry, req.Position); err != nil {
+			return err
+		}
+
+		// Close the gap left in the previous sibling list
+		if !sameParent(previousParentID, entry.ParentID) {
+			if err := renumberSiblings(tx, contentType.ID, previousParentID); err != nil {
+				return err
+			}
+		}
+
+		if err := createAuditLog(tx, c, "move", "content-entry", &entry.ID, "Moved content entry", map[string]interface{}{
+			"contentType": contentTypeUID,
+			"parentId":    entry.ParentID,
+			"position":    entry.Position,
+		}); err != nil {
+			return err
+		}
+
+		if err := createContentHistory(tx, entry.ID, "moved", "Entry moved", entry.Data, entry.UpdatedByID); err != nil {
+			return err
+		}
+
+		if err := runLifecycleHooks(tx, c, lifecycle.AfterUpdateAction, &contentType, &entry, &previous); err != nil {
+			return err
+		}
+
+		events := append([]string{webhooks.EntryUpdate}, statusWebhookEvents(previous.Status, entry.Status)...)
+		return queueEntryEvents(tx, c, &contentType, &entry, events...)
+	})
+	if err != nil {
+		respondEntryWriteError(c, err)
+		return
+	}
+
+	database.DB.Preload("CreatedBy").Preload("UpdatedBy").First(&entry, entry.ID)
+
+	// Sibling positions changed as well
+	cache.Invalidate(cache.ContentTypeTag(contentTypeUID))
+	notifyEntryEvents(c)
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// validateTreeParent checks that parentID is a valid parent for the entry.
+// entryID is 0 for entries that do not exist yet.
+func validateTreeParent(db *gorm.DB, contentType *models.ContentType, entryID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	if !contentType.IsTree {
+		return errors.New("content type is not tree-enabled")
+	}
+
+	if entryID != 0 && *parentID == entryID {
+		return errTreeCycle
+	}
+
+	var parent models.ContentEntry
+	if err := db.Where("id = ? AND content_type_id = ?", *parentID, contentType.ID).First(&parent).Error; err != nil {
+		return errors.New("parent entry not found")
+	}
+
+	if entryID == 0 {
+		return nil
+	}
+
+	// The new parent must not be a descendant of the entry
+	ancestors, err := entryAncestors(db, &parent)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == entryID {
+			return errTreeCycle
+		}
+	}
+
+	return nil
+}
+
+// entryAncestors returns the ancestors of an entry ordered from the root down
+func entryAncestors(db *gorm.DB, entry *models.ContentEntry) ([]models.ContentEntry, error) {
+	var ancestors []models.ContentEntry
+	seen := map[uint]bool{entry.ID: true}
+
+	parentID := entry.ParentID
+	for parentID != nil {
+		if seen[*parentID] || len(ancestors) >= maxTreeDepth {
+			return nil, errTreeCycle
+		}
+		seen[*parentID] = true
+
+		var parent models.ContentEntry
+		if err := db.Where("id = ? AND content_type_id = ?", *parentID, entry.ContentTypeID).First(&parent).Error; err != nil {
+			// Dangling parent reference: treat the last found node as root
+			break
+		}
+		ancestors = append([]models.ContentEntry{parent}, ancestors...)
+		parentID = parent.ParentID
+	}
+
+	return ancestors, nil
+}
+
+// placeInSiblings puts the entry at position among its siblings and renumbers them.
+// A nil position appends the entry to the end of the list.
+func placeInSiblings(db *gorm.DB, entry *models.ContentEntry, position *int) error {
+	var siblings []models.ContentEntry
+	if err := treeSiblingsQuery(db, entry.ContentTypeID, entry.ParentID).
+		Where("id <> ?", entry.ID).
+		Order("position ASC, id ASC").
+		Find(&siblings).Error; err != nil {
+		return err
+	}
+
+	index := len(siblings)
+	if position != nil && *position >= 0 && *position < len(siblings) {
+		index = *position
+	}
+
+	ordered := make([]uint, 0, len(siblings)+1)
+	for i, sibling := range siblings {
+		if i == index {
+			ordered = append(ordered, entry.ID)
+		}
+		ordered = append(ordered, sibling.ID)
+	}
+	if index == len(siblings) {
+		ordered = append(ordered, entry.ID)
+	}
+
+	for i, id := range ordered {
+		if err := db.Model(&models.ContentEntry{}).Where("id = ?", id).UpdateColumn("position", i).Error; err != nil {
+			return err
+		}
+	}
+	entry.Position = index
+
+	return nil
+}
+
+// renumberSiblings compacts sibling positions to 0..n-1 keeping their order
+func renumberSiblings(db *gorm.DB, contentTypeID uint, parentID *uint) error {
+	var siblings []models.ContentEntry
+	if err := treeSiblingsQuery(db, contentTypeID, parentID).
+		Order("position ASC, id ASC").
+		Find(&siblings).Error; err != nil {
+		return err
+	}
+
+	for i, sibling := range siblings {
+		if sibling.Position == i {
+			continue
+		}
+		if err := db.Model(&models.ContentEntry{}).Where("id = ?", sibling.ID).UpdateColumn("position", i).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextTreePosition returns the position that appends a new entry to its siblings
+func nextTreePosition(db *gorm.DB, contentTypeID uint, parentID *uint) int {
+	var count int64
+	treeSiblingsQuery(db, contentTypeID, parentID).Model(&models.ContentEntry{}).Count(&count)
+	return int(count)
+}
+
+// liftTreeChildren moves the children of a removed entry to the entry's own parent,
+// in the slot the entry occupied among its siblings
+func liftTreeChildren(db *gorm.DB, entry *models.ContentEntry) error {
+	var children []models.ContentEntry
+	if err := treeSiblingsQuery(db, entry.ContentTypeID, &entry.ID).
+		Order("position ASC, id ASC").
+		Find(&children).Error; err != nil {
+		return err
+	}
+	if len(children) == 0 {
+		return renumberSiblings(db, entry.ContentTypeID, entry.ParentID)
+	}
+
+	var siblings []models.ContentEntry
+	if err := treeSiblingsQuery(db, entry.ContentTypeID, entry.ParentID).
+		Order("position ASC, id ASC").
+		Find(&siblings).Error; err != nil {
+		return err
+	}
+
+	ordered := make([]uint, 0, len(siblings)+len(children))
+	inserted := false
+	for _, sibling := range siblings {
+		if !inserted && sibling.Position > entry.Position {
+			for _, child := range children {
+				ordered = append(ordered, child.ID)
+			}
+			inserted = true
+		}
+		ordered = append(ordered, sibling.ID)
+	}
+	if !inserted {
+		for _, child := range children {
+			ordered = append(ordered, child.ID)
+		}
+	}
+
+	for i, id := range ordered {
+		if err := db.Model(&models.ContentEntry{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"parent_id": entry.ParentID,
+			"position":  i,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func treeSiblingsQuery(db *gorm.DB, contentTypeID uint, parentID *uint) *gorm.DB {
+	query := db.Where("content_type_id = ?", contentTypeID)
+	if parentID == nil {
+		return query.Where("parent_id IS NULL")
+	}
+	return query.Where("parent_id = ?", *parentID)
+}
+
+// applyParentFilter filters a list query by the parentId query parameter.
+// "root" or "null" selects top-level entries.
+func applyParentFilter(c *gin.Context, query *gorm.DB) *gorm.DB {
+	parentID := c.Query("parentId")
+	switch parentID {
+	case "":
+		return query
+	case "root", "null":
+		return query.Where("parent_id IS NULL")
+	default:
+		id, err := strconv.ParseUint(parentID, 10, 32)
+		if err != nil {
+			return query.Where("1 = 0")
+		}
+		return query.Where("parent_id = ?", uint(id))
+	}
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// buildEntryTree nests entries under their parents. Entries must be sorted by position.
+// Entries whose parent is not part of the list are dropped together with their subtree,
+// so filtering the input (e.g. by status) hides whole branches.
+func buildEntryTree(entries []models.ContentEntry, format func(*models.ContentEntry) map[string]interface{}) []map[string]interface{} {
+	present := make(map[uint]bool, len(entries))
+	for _, entry := range entries {
+		present[entry.ID] = true
+	}
+
+	children := make(map[uint][]*models.ContentEntry)
+	var roots []*models.ContentEntry
+	for i := range entries {
+		entry := &entries[i]
+		if entry.ParentID == nil {
+			roots = append(roots, entry)
+			continue
+		}
+		if present[*entry.ParentID] {
+			children[*entry.ParentID] = append(children[*entry.ParentID], entry)
+		}
+	}
+
+	var build func(nodes []*models.ContentEntry, depth int) []map[string]interface{}
+	build = func(nodes []*models.ContentEntry, depth int) []map[string]interface{} {
+		result := make([]map[string]interface{}, 0, len(nodes))
+		for _, node := range nodes {
+			item := format(node)
+			if depth < maxTreeDepth {
+				item["children"] = build(children[node.ID], depth+1)
+			}
+			result = append(result, item)
+		}
+		return result
+	}
+
+	return build(roots, 0)
+}
+
+func adminTreeEntryMap(entry *models.ContentEntry) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            entry.ID,
+		"createdAt":     entry.CreatedAt,
+		"updatedAt":     entry.UpdatedAt,
+		"publishedAt":   entry.PublishedAt,
+		"contentTypeId": entry.ContentTypeID,
+		"data":          entry.Data,
+		"status":        entry.Status,
+		"parentId":      entry.ParentID,
+		"position":      entry.Position,
+		"createdById":   entry.CreatedByID,
+		"updatedById":   entry.UpdatedByID,
+	}
+}
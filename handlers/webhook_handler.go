@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"github.com/xivercms/xivercms/webhooks"
+	"gorm.io/gorm"
+)
+
+func GetWebhooks(c *gin.Context) {
+	var hooks []models.Webhook
+	if err := database.DB.Preload("CreatedBy").Order("created_at DESC").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range hooks {
+		maskWebhookSecret(&hooks[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": hooks})
+}
+
+func GetWebhook(c *gin.Context) {
+	id := c.Param("id")
+	var hook models.Webhook
+
+	if err := database.DB.Preload("CreatedBy").First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	maskWebhookSecret(&hook)
+	c.JSON(http.StatusOK, hook)
+}
+
+type WebhookRequest struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Secret       string   `json:"secret"`
+	Enabled      *bool    `json:"enabled"`
+	Events       []string `json:"events"`
+	ContentTypes []string `json:"contentTypes"`
+}
+
+func CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook := models.Webhook{
+		Name:         req.Name,
+		URL:          req.URL,
+		Secret:       req.Secret,
+		Enabled:      true,
+		Events:       req.Events,
+		ContentTypes: req.ContentTypes,
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+
+	// Generate a secret unless one is given
+	if hook.Secret == "" {
+		secretBytes := make([]byte, 24)
+		rand.Read(secretBytes)
+		hook.Secret = "whsec_" + hex.EncodeToString(secretBytes)
+	}
+
+	if err := validateWebhook(&hook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	userID := userId.(uint)
+	hook.CreatedByID = &userID
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hook).Error; err != nil {
+			return err
+		}
+		// An explicit false is replaced by the column default on create
+		return tx.Model(&hook).Update("enabled", hook.Enabled).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "create", "webhook", &hook.ID, "Created webhook", map[string]interface{}{
+		"url":    hook.URL,
+		"events": hook.Events,
+	})
+
+	// Return full secret only on creation
+	c.JSON(http.StatusCreated, hook)
+}
+
+func UpdateWebhook(c *gin.Context) {
+	id := c.Param("id")
+	var hook models.Webhook
+
+	if err := database.DB.First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		hook.Name = req.Name
+	}
+	if req.URL != "" {
+		hook.URL = req.URL
+	}
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if req.Events != nil {
+		hook.Events = req.Events
+	}
+	if req.ContentTypes != nil {
+		hook.ContentTypes = req.ContentTypes
+	}
+
+	if err := validateWebhook(&hook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "update", "webhook", &hook.ID, "Updated webhook", map[string]interface{}{
+		"url":     hook.URL,
+		"events":  hook.Events,
+		"enabled": hook.Enabled,
+	})
+
+	maskWebhookSecret(&hook)
+	c.JSON(http.StatusOK, hook)
+}
+
+func DeleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+	var hook models.Webhook
+
+	if err := database.DB.First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	// Pending deliveries are dropped with the webhook, the log of past ones is kept
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		pending := tx.Model(&models.WebhookDelivery{}).Select("id").Where("webhook_id = ? AND status = ?", hook.ID, "pending")
+		if err := tx.Where("delivery_id IN (?)", pending).Delete(&models.WebhookDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ? AND status = ?", hook.ID, "pending").Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&hook).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	CreateAuditLog(c, "delete", "webhook", &hook.ID, "Deleted webhook", map[string]interface{}{
+		"url": hook.URL,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, newest first, with the result
+// of every attempt of each delivery
+func GetWebhookDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	var hook models.Webhook
+
+	if err := database.DB.First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	offset := (page - 1) * pageSize
+
+	query := database.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID)
+
+	// Filter by status: pending, sending, success, failed
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Offset(offset).Limit(pageSize).Order("id DESC").Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+		"meta": gin.H{
+			"pagination": gin.H{
+				"page":     page,
+				"pageSize": pageSize,
+				"total":    total,
+			},
+		},
+	})
+}
+
+// TestWebhook queues a webhook.test ping to the endpoint
+func TestWebhook(c *gin.Context) {
+	id := c.Param("id")
+	var hook models.Webhook
+
+	if err := database.DB.First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	delivery, err := webhooks.EnqueueFor(database.DB, &hook, webhooks.WebhookTest, "", map[string]interface{}{
+		"webhook": gin.H{"id": hook.ID, "name": hook.Name},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	webhooks.Notify()
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// RetryWebhookDelivery queues a delivery again with a fresh set of attempts
+func RetryWebhookDelivery(c *gin.Context) {
+	var delivery models.WebhookDelivery
+	if err := database.DB.Where("id = ? AND webhook_id = ?", c.Param("deliveryId"), c.Param("id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	if delivery.Status == "pending" || delivery.Status == "sending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Delivery is already queued"})
+		return
+	}
+
+	if err := database.DB.Model(&delivery).Updates(map[string]interface{}{
+		"status":          "pending",
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	webhooks.Notify()
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func validateWebhook(hook *models.Webhook) error {
+	if hook.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	if len(hook.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range hook.Events {
+		if !webhooks.IsEvent(event) {
+			return fmt.Errorf("unknown event %q, use one of %v", event, webhooks.Events)
+		}
+	}
+
+	for _, uid := range hook.ContentTypes {
+		var contentType models.ContentType
+		if err := database.DB.Where("uid = ?", uid).First(&contentType).Error; err != nil {
+			return fmt.Errorf("content type %s not found", uid)
+		}
+	}
+
+	return nil
+}
+
+// maskWebhookSecret shows only the first 8 characters of the secret
+func maskWebhookSecret(hook *models.Webhook) {
+	if len(hook.Secret) > 8 {
+		hook.Secret = hook.Secret[:8] + "..."
+	}
+}
+
+// queueEntryWebhooks queues webhook events of an entry change inside its transaction
+func queueEntryWebhooks(tx *gorm.DB, contentTypeUID string, entry *models.ContentEntry, events ...string) error {
+	for _, event := range events {
+		if err := webhooks.Enqueue(tx, event, contentTypeUID, map[string]interface{}{
+			"entry": publicEntryMap(entry),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusWebhookEvents returns the publish or unpublish event of a status change
+func statusWebhookEvents(previousStatus, status string) []string {
+	switch {
+	case status == "published" && previousStatus != "published":
+		return []string{webhooks.EntryPublish}
+	case previousStatus == "published" && status != "published":
+		return []string{webhooks.EntryUnpublish}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeContext is what entry writes need from their caller: the acting user under the
+// "userId" key, the client for the audit log, and a place to queue events and
+// notifications that are published after the commit. *gin.Context implements it for
+// requests, backgroundWrite for writes made by the server itself.
+type writeContext interface {
+	Get(key string) (value any, exists bool)
+	Set(key string, value any)
+	ClientIP() string
+	GetHeader(key string) string
+}
+
+// backgroundWrite is the writeContext of a write without a request, e.g. a release
+// executed by the scheduler
+type backgroundWrite struct {
+	keys      map[string]any
+	userAgent string
+}
+
+// newBackgroundWrite makes changes on behalf of userID, which may be nil. userAgent names
+// the job in the audit log.
+func newBackgroundWrite(userID *uint, userAgent string) *backgroundWrite {
+	w := &backgroundWrite{keys: make(map[string]any), userAgent: userAgent}
+	if userID != nil {
+		w.keys["userId"] = *userID
+	}
+	return w
+}
+
+func (w *backgroundWrite) Get(key string) (any, bool) {
+	value, exists := w.keys[key]
+	return value, exists
+}
+
+func (w *backgroundWrite) Set(key string, value any) { w.keys[key] = value }
+
+func (w *backgroundWrite) ClientIP() string { return "" }
+
+func (w *backgroundWrite) GetHeader(key string) string {
+	if key == "User-Agent" {
+		return w.userAgent
+	}
+	return ""
+}
+
+// writeRequestContext is the context lifecycle hooks of a write run with: the request's,
+// which is canceled when the client goes away
+func writeRequestContext(w writeContext) context.Context {
+	if c, ok := w.(*gin.Context); ok && c.Request != nil {
+		return c.Request.Context()
+	}
+	return context.Background()
+}
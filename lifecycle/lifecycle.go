@@ -0,0 +1,117 @@
+// Package lifecycle is a registry of Go hooks run around content entry writes.
+//
+// Hooks are registered per content type UID (or "*" for every type), usually from an
+// init function or main before the server starts:
+//
+//	lifecycle.BeforeCreate("articles", func(e *lifecycle.Event) error {
+//		if e.Entry.Data["title"] == "" {
+//			return lifecycle.Reject("Title is required", map[string]string{"title": "required"})
+//		}
+//		e.Entry.Data["wordCount"] = countWords(e.Entry.Data["body"])
+//		return nil
+//	})
+//
+// Hooks run inside the transaction of the write. An error returned by any hook rolls the
+// whole write back; a *ValidationError is reported to the client as 400 Bad Request.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+// Action is the point of an entry write a hook runs at
+type Action string
+
+const (
+	BeforeCreateAction Action = "beforeCreate"
+	AfterCreateAction  Action = "afterCreate"
+	BeforeUpdateAction Action = "beforeUpdate"
+	AfterUpdateAction  Action = "afterUpdate"
+	BeforeDeleteAction Action = "beforeDelete"
+	AfterDeleteAction  Action = "afterDelete"
+)
+
+// AllContentTypes registers a hook for every content type
+const AllContentTypes = "*"
+
+// Event is passed to hooks
+type Event struct {
+	Action      Action
+	ContentType *models.ContentType
+
+	// Entry being written. Before hooks may change Data and Status; before create the
+	// entry has no ID yet. Relation fields are not part of Data.
+	Entry *models.ContentEntry
+	// Previous is the entry before an update, nil for other actions
+	Previous *models.ContentEntry
+
+	// Tx is the transaction of the write, use it for any database access
+	Tx *gorm.DB
+	// UserID is the user performing the write, nil if unknown
+	UserID *uint
+	// Context of the request
+	Context context.Context
+}
+
+// Hook is run around an entry write. Returning an error aborts and rolls back the write.
+type Hook func(e *Event) error
+
+// ValidationError rejects a write as invalid input
+type ValidationError struct {
+	Message string
+	Fields  map[string]string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+// Reject returns a ValidationError, fields maps field names to messages and may be nil
+func Reject(message string, fields map[string]string) error {
+	return &ValidationError{Message: message, Fields: fields}
+}
+
+var registry = struct {
+	sync.RWMutex
+	hooks map[Action]map[string][]Hook
+}{hooks: make(map[Action]map[string][]Hook)}
+
+// On registers a hook for an action on a content type UID or AllContentTypes.
+// Hooks run in registration order, hooks for all types first.
+func On(action Action, contentTypeUID string, hook Hook) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.hooks[action] == nil {
+		registry.hooks[action] = make(map[string][]Hook)
+	}
+	registry.hooks[action][contentTypeUID] = append(registry.hooks[action][contentTypeUID], hook)
+}
+
+func BeforeCreate(contentTypeUID string, hook Hook) { On(BeforeCreateAction, contentTypeUID, hook) }
+func AfterCreate(contentTypeUID string, hook Hook)  { On(AfterCreateAction, contentTypeUID, hook) }
+func BeforeUpdate(contentTypeUID string, hook Hook) { On(BeforeUpdateAction, contentTypeUID, hook) }
+func AfterUpdate(contentTypeUID string, hook Hook)  { On(AfterUpdateAction, contentTypeUID, hook) }
+func BeforeDelete(contentTypeUID string, hook Hook) { On(BeforeDeleteAction, contentTypeUID, hook) }
+func AfterDelete(contentTypeUID string, hook Hook)  { On(AfterDeleteAction, contentTypeUID, hook) }
+
+// Run calls the hooks registered for the event's action and content type, stopping at
+// the first error
+func Run(e *Event) error {
+	registry.RLock()
+	var hooks []Hook
+	hooks = append(hooks, registry.hooks[e.Action][AllContentTypes]...)
+	if e.ContentType.UID != AllContentTypes {
+		hooks = append(hooks, registry.hooks[e.Action][e.ContentType.UID]...)
+	}
+	registry.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
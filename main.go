@@ -2,13 +2,20 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xivercms/xivercms/auth"
+	"github.com/xivercms/xivercms/cache"
 	"github.com/xivercms/xivercms/config"
 	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/handlers"
 	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/notifications"
+	"github.com/xivercms/xivercms/plugins"
 	"github.com/xivercms/xivercms/routes"
+	"github.com/xivercms/xivercms/submissions"
+	"github.com/xivercms/xivercms/webhooks"
 )
 
 func main() {
@@ -18,18 +25,77 @@ func main() {
 	// Initialize auth
 	auth.InitAuth(config.AppConfig.JWTSecret)
 
+	// Enable the plugins listed in PLUGINS
+	if err := plugins.Load(config.AppConfig.Plugins); err != nil {
+		log.Fatal("Failed to load plugins:", err)
+	}
+
 	// Connect to database
 	database.Connect()
 
-	// Run migrations
-	database.Migrate()
+	// Run migrations, including the models of enabled plugins
+	database.Migrate(plugins.Models()...)
 
 	// Seed initial data
 	database.Seed()
 
+	// Response cache for public entry reads
+	if config.AppConfig.CacheEnabled {
+		ttl, err := time.ParseDuration(config.AppConfig.CacheTTL)
+		if err != nil {
+			ttl = 5 * time.Minute
+		}
+		cache.Init(cache.NewLRU(config.AppConfig.CacheSize), ttl)
+		log.Printf("Response cache enabled (%d entries, TTL %s)", config.AppConfig.CacheSize, ttl)
+	}
+
+	// Webhook delivery worker
+	retryBase, _ := time.ParseDuration(config.AppConfig.WebhookRetryBase)
+	timeout, _ := time.ParseDuration(config.AppConfig.WebhookTimeout)
+	webhooks.Start(webhooks.Options{
+		MaxAttempts: config.AppConfig.WebhookMaxAttempts,
+		RetryBase:   retryBase,
+		Timeout:     timeout,
+	})
+
+	// CAPTCHA verification of public submissions
+	if config.AppConfig.CaptchaProvider != "" {
+		verifier, err := submissions.NewSiteVerifier(config.AppConfig.CaptchaProvider, config.AppConfig.CaptchaSecret)
+		if err != nil {
+			log.Fatal("Failed to configure CAPTCHA:", err)
+		}
+		submissions.SetVerifier(verifier)
+	}
+
+	// Notification emails, written to files for development
+	if config.AppConfig.MailSinkDir != "" {
+		sink, err := notifications.NewFileSink(config.AppConfig.MailSinkDir)
+		if err != nil {
+			log.Fatal("Failed to configure mail sink:", err)
+		}
+		notifications.Register(notifications.NewEmailChannel(sink, config.AppConfig.MailFrom, config.AppConfig.CORSOrigin))
+		log.Printf("Notification emails are written to %s", config.AppConfig.MailSinkDir)
+	}
+
+	// Executes releases at their scheduled time
+	handlers.StartReleaseScheduler()
+
 	// Setup Gin router
 	gin.SetMode(config.AppConfig.GinMode)
-	r := gin.Default()
+	r := gin.New()
+	// Stream connections pass the JWT in the query string, the access log leaves it out
+	r.Use(middleware.LoggerMiddleware(), gin.Recovery())
+
+	// Client IPs are taken from X-Forwarded-For only behind configured proxies, otherwise
+	// anyone could pick their IP, e.g. to get around the submission rate limit. The same
+	// holds for the host and scheme in X-Forwarded-Host and X-Forwarded-Proto, which
+	// feeds and the sitemap build their links on.
+	if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	if err := middleware.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
 	// Middleware
 	r.Use(middleware.CORSMiddleware())
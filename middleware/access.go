@@ -125,3 +125,56 @@ func CheckContentTypeAccess(contentTypeUID string, c *gin.Context) bool {
 
 	return false
 }
+
+// Access levels of a client, from the least to the most privileged
+const (
+	AccessLevelPublic        = "public"
+	AccessLevelAuthenticated = "authenticated"
+	AccessLevelModerator     = "moderator"
+	AccessLevelAdmin         = "admin"
+	AccessLevelSuperAdmin    = "superadmin"
+)
+
+// ContentTypeAccessLevel returns the access level of the current client, i.e. the most
+// restrictive content type access type it passes in CheckContentTypeAccess
+func ContentTypeAccessLevel(c *gin.Context) string {
+	userId, exists := c.Get("userId")
+	if !exists {
+		return AccessLevelPublic
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Roles").First(&user, userId).Error; err != nil {
+		return AccessLevelAuthenticated
+	}
+	if user.IsSuperAdmin {
+		return AccessLevelSuperAdmin
+	}
+
+	level := AccessLevelAuthenticated
+	for _, role := range user.Roles {
+		if role.Name == "Admin" {
+			return AccessLevelAdmin
+		}
+		if role.Name == "Moderator" {
+			level = AccessLevelModerator
+		}
+	}
+	return level
+}
+
+// AccessLevelAllows reports whether a client with the given access level can access
+// content types with the given access type
+func AccessLevelAllows(level, accessType string) bool {
+	switch level {
+	case AccessLevelSuperAdmin:
+		return true
+	case AccessLevelAdmin:
+		return accessType == "public" || accessType == "authenticated" || accessType == "moderator" || accessType == "admin"
+	case AccessLevelModerator:
+		return accessType == "public" || accessType == "authenticated" || accessType == "moderator"
+	case AccessLevelAuthenticated:
+		return accessType == "public" || accessType == "authenticated"
+	}
+	return accessType == "public"
+}
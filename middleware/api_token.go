@@ -13,56 +13,72 @@ import (
 // APITokenMiddleware validates API tokens for programmatic access
 func APITokenMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.Next() // Continue to next middleware (JWT auth)
+		if !authenticateAPIToken(c) {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 {
-			c.Next() // Not an API token, continue to JWT
+		// For read-only tokens, restrict to GET requests
+		if c.GetString("apiTokenType") == "read-only" && c.Request.Method != "GET" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Read-only token cannot perform this action"})
+			c.Abort()
 			return
 		}
 
-		// Check if it's an API token (starts with xvc_)
-		if !strings.HasPrefix(parts[1], "xvc_") {
-			c.Next() // Not an API token, continue to JWT
-			return
-		}
+		c.Next()
+	}
+}
 
-		// Validate API token
-		var token models.APIToken
-		if err := database.DB.Where("token = ?", parts[1]).First(&token).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
-			c.Abort()
-			return
+// OptionalAPITokenMiddleware validates API tokens like APITokenMiddleware but leaves the
+// check of read-only tokens to the handler. GraphQL sends queries and mutations as POST.
+func OptionalAPITokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticateAPIToken(c) {
+			c.Next()
 		}
+	}
+}
 
-		// Check if token is expired
-		if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "API token expired"})
-			c.Abort()
-			return
-		}
+// authenticateAPIToken sets the token info of a request with an API token in the
+// Authorization header. It responds 401 and returns false for an invalid or expired token.
+func authenticateAPIToken(c *gin.Context) bool {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return true // Continue to next middleware (JWT auth)
+	}
 
-		// Update last used timestamp
-		now := time.Now()
-		token.LastUsedAt = &now
-		database.DB.Save(&token)
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 {
+		return true // Not an API token, continue to JWT
+	}
 
-		// Set token info in context
-		c.Set("apiTokenId", token.ID)
-		c.Set("apiTokenType", token.Type)
-		c.Set("apiTokenUserId", token.CreatedByID)
+	// Check if it's an API token (starts with xvc_)
+	if !strings.HasPrefix(parts[1], "xvc_") {
+		return true // Not an API token, continue to JWT
+	}
 
-		// For read-only tokens, restrict to GET requests
-		if token.Type == "read-only" && c.Request.Method != "GET" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Read-only token cannot perform this action"})
-			c.Abort()
-			return
-		}
+	// Validate API token
+	var token models.APIToken
+	if err := database.DB.Where("token = ?", parts[1]).First(&token).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
+		c.Abort()
+		return false
+	}
 
-		c.Next()
+	// Check if token is expired
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "API token expired"})
+		c.Abort()
+		return false
 	}
+
+	// Update last used timestamp
+	now := time.Now()
+	token.LastUsedAt = &now
+	database.DB.Save(&token)
+
+	// Set token info in context
+	c.Set("apiTokenId", token.ID)
+	c.Set("apiTokenType", token.Type)
+	c.Set("apiTokenUserId", token.CreatedByID)
+	return true
 }
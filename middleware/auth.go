@@ -68,9 +68,24 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			if claims, err := auth.ValidateToken(token); err == nil {
 				c.Set("userId", claims.UserID)
 				c.Set("email", claims.Email)
+				if claims.ExpiresAt != nil {
+					c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+				}
 			}
 		}
 
 		c.Next()
 	}
 }
+
+// QueryTokenMiddleware accepts a JWT in the access_token query parameter when no
+// Authorization header is sent. Browsers cannot set headers on EventSource and
+// WebSocket connections.
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trustedProxies are the networks whose forwarding headers are honoured
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the IP addresses or CIDRs of the reverse proxies in front of the
+// server. Like gin's own setting for X-Forwarded-For, it decides whether X-Forwarded-Proto
+// and X-Forwarded-Host of a request are trusted.
+func SetTrustedProxies(proxies []string) error {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid proxy address %q", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return err
+		}
+		networks = append(networks, network)
+	}
+	trustedProxies = networks
+	return nil
+}
+
+// ForwardedHeader returns a forwarding header of the request, or an empty string unless
+// the connection comes from a trusted proxy: anyone else could set it to any value
+func ForwardedHeader(c *gin.Context, name string) string {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return ""
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return c.GetHeader(name)
+		}
+	}
+	return ""
+}
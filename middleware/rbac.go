@@ -33,21 +33,7 @@ func RBACMiddleware(action, subject string) gin.HandlerFunc {
 		}
 
 		// Check if user has permission through roles
-		hasPermission := false
-		for _, role := range user.Roles {
-			for _, permission := range role.Permissions {
-				// Check if permission matches
-				if permissionMatches(permission, action, subject, c) {
-					hasPermission = true
-					break
-				}
-			}
-			if hasPermission {
-				break
-			}
-		}
-
-		if !hasPermission {
+		if !userHasPermission(&user, action, subject, c) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			c.Abort()
 			return
@@ -57,6 +43,38 @@ func RBACMiddleware(action, subject string) gin.HandlerFunc {
 	}
 }
 
+// HasPermission reports whether the authenticated user may perform action on subject,
+// for checks that depend on the resource, e.g. deleting someone else's comment
+func HasPermission(c *gin.Context, action, subject string) bool {
+	userId, exists := c.Get("userId")
+	if !exists {
+		return false
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Roles").Preload("Roles.Permissions").First(&user, userId).Error; err != nil {
+		return false
+	}
+	return user.IsSuperAdmin || userHasPermission(&user, action, subject, c)
+}
+
+// UserHasPermission reports whether a user with preloaded roles and permissions may
+// perform action on subject, without the super admin shortcut
+func UserHasPermission(user *models.User, action, subject string) bool {
+	return userHasPermission(user, action, subject, nil)
+}
+
+func userHasPermission(user *models.User, action, subject string, c *gin.Context) bool {
+	for _, role := range user.Roles {
+		for _, permission := range role.Permissions {
+			if permissionMatches(permission, action, subject, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func permissionMatches(permission models.Permission, action, subject string, c *gin.Context) bool {
 	// Check action (wildcard support)
 	if permission.Action != "all" && permission.Action != action {
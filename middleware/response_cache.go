@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/cache"
+)
+
+// responseRecorder captures the response body while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// cachedHeaders are the response headers replayed from the cache
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Cache-Control", "Vary"}
+
+// ResponseCacheMiddleware serves anonymous GET requests from the response cache.
+// Handlers opt in by attaching invalidation tags with cache.AddTags; only
+// 200 responses with tags are stored. Authenticated and preview requests bypass the cache.
+func ResponseCacheMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		store := cache.Default
+		if store == nil || c.Request.Method != http.MethodGet || c.GetHeader("Authorization") != "" || isPreviewRequest(c) {
+			c.Next()
+			return
+		}
+
+		// Responses such as feeds contain absolute URLs built from the host the request was
+		// made to, so the origin is part of the key
+		key := requestOrigin(c) + c.Request.URL.Path + "?" + c.Request.URL.Query().Encode()
+
+		if entry, ok := store.Get(key); ok {
+			for _, name := range cachedHeaders {
+				if value := entry.Header.Get(name); value != "" {
+					c.Header(name, value)
+				}
+			}
+			c.Header("X-Cache", "HIT")
+
+			if notModified(c, entry.Header) {
+				c.AbortWithStatus(http.StatusNotModified)
+				return
+			}
+
+			c.Data(entry.Status, entry.Header.Get("Content-Type"), entry.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Header("X-Cache", "MISS")
+
+		// A write invalidating the response's tags while the handler runs may have
+		// happened after the handler read its data, such a response is not stored
+		generation := store.Generation()
+
+		c.Next()
+
+		tags := cache.TagsFromContext(c)
+		if recorder.Status() != http.StatusOK || len(tags) == 0 || recorder.body.Len() == 0 {
+			return
+		}
+
+		header := http.Header{}
+		for _, name := range cachedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+
+		store.SetFresh(key, &cache.Entry{
+			Status: recorder.Status(),
+			Header: header,
+			Body:   append([]byte(nil), recorder.body.Bytes()...),
+			Tags:   tags,
+		}, generation)
+	}
+}
+
+// requestOrigin identifies the host a request was made to, including the headers of
+// trusted proxies
+func requestOrigin(c *gin.Context) string {
+	return ForwardedHeader(c, "X-Forwarded-Proto") + "|" + ForwardedHeader(c, "X-Forwarded-Host") + "|" + c.Request.Host + "|"
+}
+
+// notModified evaluates conditional request headers against cached validators
+func notModified(c *gin.Context, header http.Header) bool {
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		etag := header.Get("ETag")
+		return etag != "" && cache.ETagMatches(ifNoneMatch, etag)
+	}
+
+	if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince != "" {
+		lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+		if err != nil {
+			return false
+		}
+		since, err := http.ParseTime(ifModifiedSince)
+		return err == nil && !lastModified.After(since)
+	}
+
+	return false
+}
+
+// isPreviewRequest reports whether a request carries a preview token, which may show drafts
+func isPreviewRequest(c *gin.Context) bool {
+	return c.GetHeader("X-Preview-Token") != "" || c.Query("previewToken") != ""
+}
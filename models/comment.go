@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is an editorial note on a content entry, optionally about one field. Replies
+// point to the comment that starts the thread; only threads are resolved.
+type Comment struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ContentTypeUID string `json:"contentType" gorm:"not null;index:idx_comment_entry"`
+	EntryID        uint   `json:"entryId" gorm:"not null;index:idx_comment_entry"`
+	Field          string `json:"field,omitempty"`       // Empty for comments on the whole entry
+	ParentID       *uint  `json:"parentId" gorm:"index"` // Nil for the first comment of a thread
+	Body           string `json:"body" gorm:"type:text;not null"`
+	Removed        bool   `json:"removed" gorm:"default:false"` // Deleted, but kept because the thread has replies
+
+	AuthorID uint       `json:"authorId" gorm:"not null"`
+	Author   *User      `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	EditedAt *time.Time `json:"editedAt"`
+
+	Resolved     bool       `json:"resolved" gorm:"default:false"`
+	ResolvedAt   *time.Time `json:"resolvedAt"`
+	ResolvedByID *uint      `json:"resolvedById"`
+
+	Mentions []User    `json:"mentions" gorm:"many2many:comment_mentions;"`
+	Replies  []Comment `json:"replies,omitempty" gorm:"foreignKey:ParentID"`
+}
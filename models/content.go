@@ -45,6 +45,21 @@ type ContentType struct {
 	Description string `json:"description"`
 	IsVisible   bool   `json:"isVisible" gorm:"default:true"`
 	AccessType  string `json:"accessType" gorm:"default:public"` // public, authenticated, moderator, admin
+	IsTree      bool   `json:"isTree" gorm:"default:false"`      // Entries can be nested (pages, menus)
+	URLPattern  string `json:"urlPattern"`                       // Frontend path of an entry, e.g. /blog/{slug}
+	InSitemap   bool   `json:"inSitemap" gorm:"default:false"`   // Published entries are listed in sitemap.xml, requires URLPattern
+
+	// HTTP cache policy for public entry endpoints, in seconds.
+	// With zero max-age clients must revalidate with ETag / Last-Modified.
+	CacheMaxAge               int `json:"cacheMaxAge" gorm:"default:0"`
+	CacheSMaxAge              int `json:"cacheSMaxAge" gorm:"default:0"` // Shared caches (CDN), public responses only
+	CacheStaleWhileRevalidate int `json:"cacheStaleWhileRevalidate" gorm:"default:0"`
+
+	// RSS, Atom and JSON Feed of published entries
+	Feed FeedConfig `json:"feed" gorm:"type:text"`
+
+	// Anonymous creation of draft entries through POST /api/{uid}
+	Submissions SubmissionConfig `json:"submissions" gorm:"type:text"`
 
 	// Schema definition stored as JSON
 	Schema JSONB `json:"schema" gorm:"type:jsonb"`
@@ -69,6 +84,14 @@ type ContentEntry struct {
 	// Status: draft, published
 	Status string `json:"status" gorm:"default:draft"`
 
+	// Restricted entries can only be read through the public API by the roles and users
+	// in their access list (EntryAccess)
+	Restricted bool `json:"restricted" gorm:"default:false;index"`
+
+	// Tree structure, used when the content type has IsTree enabled
+	ParentID *uint `json:"parentId" gorm:"index"`
+	Position int   `json:"position" gorm:"default:0"` // Order among siblings
+
 	// Created by user
 	CreatedByID *uint `json:"createdById"`
 	CreatedBy   *User `json:"createdBy,omitempty" gorm:"foreignKey:CreatedByID"`
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// EntryAccess grants a role or a user read access to a restricted entry. Exactly one of
+// RoleID and UserID is set.
+type EntryAccess struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	ContentEntryID uint  `json:"contentEntryId" gorm:"not null;index"`
+	RoleID         *uint `json:"roleId" gorm:"index"`
+	Role           *Role `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+	UserID         *uint `json:"userId" gorm:"index"`
+	User           *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// FeedConfig publishes a content type as RSS, Atom and JSON Feed
+type FeedConfig struct {
+	Enabled     bool       `json:"enabled"`
+	Title       string     `json:"title,omitempty"`       // Defaults to the display name
+	Description string     `json:"description,omitempty"` // Defaults to the content type description
+	Limit       int        `json:"limit,omitempty"`       // Number of items, 20 by default
+	Fields      FeedFields `json:"fields"`
+}
+
+// FeedFields name the schema fields feed items are built from
+type FeedFields struct {
+	Title   string `json:"title,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Content string `json:"content,omitempty"` // HTML
+	Author  string `json:"author,omitempty"`
+	Date    string `json:"date,omitempty"` // Defaults to the publication date
+}
+
+func (f *FeedConfig) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		*f = FeedConfig{}
+		return nil
+	}
+	if len(bytes) == 0 {
+		*f = FeedConfig{}
+		return nil
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+func (f FeedConfig) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(f)
+	return string(bytes), err
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification types
+const (
+	NotificationMention       = "comment.mention"
+	NotificationReply         = "comment.reply"
+	NotificationEntryStatus   = "entry.status"
+	NotificationEntryDelete   = "entry.delete"
+	NotificationSubmission    = "entry.submit"
+	NotificationReleaseFailed = "release.failed"
+)
+
+// Notification tells a user about something that happened to their work, e.g. a mention
+// in a comment or a scheduled release that failed
+type Notification struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+
+	UserID  uint   `json:"userId" gorm:"not null;index"`
+	Type    string `json:"type" gorm:"not null;index"`
+	Title   string `json:"title" gorm:"not null"`
+	Body    string `json:"body" gorm:"type:text"`
+	Link    string `json:"link"` // Path in the admin UI
+	Data    JSONB  `json:"data,omitempty" gorm:"type:jsonb"`
+	ActorID *uint  `json:"actorId"` // User whose action caused the notification
+	Actor   *User  `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+
+	ReadAt *time.Time `json:"readAt" gorm:"index"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PreviewToken lets reviewers without an account read unpublished entries through the
+// public API. The signed token itself is only returned when it is created.
+type PreviewToken struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Name           string    `json:"name"`
+	ContentTypeUID string    `json:"contentType" gorm:"not null;index"`
+	EntryID        *uint     `json:"entryId" gorm:"index"` // Nil for every entry of the content type
+	ExpiresAt      time.Time `json:"expiresAt" gorm:"not null"`
+
+	RevokedAt   *time.Time `json:"revokedAt"`
+	RevokedByID *uint      `json:"revokedById"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	UseCount    int        `json:"useCount" gorm:"default:0"`
+	CreatedByID *uint      `json:"createdById"`
+	CreatedBy   *User      `json:"createdBy,omitempty" gorm:"foreignKey:CreatedByID"`
+}
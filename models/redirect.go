@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Redirect maps an old public path to its new location
+type Redirect struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	FromPath   string `json:"fromPath" gorm:"uniqueIndex;not null"` // e.g. /blog/old-slug
+	ToPath     string `json:"toPath"`                               // Empty for 410 rules
+	StatusCode int    `json:"statusCode" gorm:"default:301"`        // 301, 302, 410
+
+	// Entry the rule was created for. A rule pointing to an unpublished or
+	// deleted entry resolves to 410 Gone.
+	ContentTypeUID string `json:"contentTypeUid" gorm:"index"`
+	EntryID        *uint  `json:"entryId" gorm:"index"`
+	IsAutomatic    bool   `json:"isAutomatic" gorm:"default:false"` // Created on slug change
+
+	CreatedByID *uint `json:"createdById"`
+	CreatedBy   *User `json:"createdBy,omitempty" gorm:"foreignKey:CreatedByID"`
+}
+
+// SlugHistory records previous slugs of content entries
+type SlugHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	ContentTypeUID string `json:"contentTypeUid" gorm:"not null;index"`
+	ContentEntryID uint   `json:"contentEntryId" gorm:"not null;index"`
+	FieldName      string `json:"fieldName" gorm:"not null"`
+	Slug           string `json:"slug" gorm:"not null;index"`
+}
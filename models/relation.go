@@ -32,7 +32,7 @@ type ContentRelation struct {
 // ContentField defines a field in content type schema
 type ContentField struct {
 	Name        string      `json:"name"`
-	Type        string      `json:"type"` // string, text, number, boolean, date, relation, media, component
+	Type        string      `json:"type"` // string, text, number, boolean, date, relation, media, component, uid
 	Required    bool        `json:"required"`
 	Unique      bool        `json:"unique"`
 	Default     interface{} `json:"default,omitempty"`
@@ -42,6 +42,19 @@ type ContentField struct {
 	RelationType      string `json:"relationType,omitempty"`      // oneToOne, oneToMany, manyToOne, manyToMany
 	TargetContentType string `json:"targetContentType,omitempty"` // UID of target content type
 
+	// Bidirectional relations: the owning side names the inverse field with InversedBy,
+	// the inverse side names the owning field with MappedBy. Relations are stored on the
+	// owning side only, the inverse field is resolved from them.
+	InversedBy string `json:"inversedBy,omitempty"`
+	MappedBy   string `json:"mappedBy,omitempty"`
+
+	// What happens to the relation when the target entry is deleted:
+	// restrict, cascade, set-null (to-one default) or detach (to-many default)
+	OnDelete string `json:"onDelete,omitempty"`
+
+	// For uid (slug) type: field the slug is generated from
+	TargetField string `json:"targetField,omitempty"`
+
 	// For media type
 	Multiple bool `json:"multiple,omitempty"` // Single file or multiple files
 
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Release statuses
+const (
+	ReleasePending  = "pending"  // Editable, executed on demand or at ScheduledAt
+	ReleaseDone     = "done"     // Executed, can be reverted
+	ReleaseFailed   = "failed"   // Scheduled execution failed, see Error
+	ReleaseReverted = "reverted" // Executed and reverted
+)
+
+// Release groups entry actions that are executed together in one transaction
+type Release struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Name        string     `json:"name" gorm:"not null"`
+	Description string     `json:"description"`
+	Status      string     `json:"status" gorm:"default:pending;index"`
+	ScheduledAt *time.Time `json:"scheduledAt" gorm:"index"` // Executed automatically at this time if pending
+	Error       string     `json:"error,omitempty"`          // Why the last scheduled execution failed
+
+	ExecutedAt   *time.Time `json:"executedAt"`
+	ExecutedByID *uint      `json:"executedById"`
+	RevertedAt   *time.Time `json:"revertedAt"`
+	RevertedByID *uint      `json:"revertedById"`
+
+	CreatedByID *uint `json:"createdById"`
+	CreatedBy   *User `json:"createdBy,omitempty" gorm:"foreignKey:CreatedByID"`
+
+	Actions []ReleaseAction `json:"actions" gorm:"foreignKey:ReleaseID"`
+}
+
+// ReleaseAction is the publish or unpublish of an entry in a release
+type ReleaseAction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	ReleaseID      uint   `json:"releaseId" gorm:"not null;uniqueIndex:idx_release_action_entry"`
+	ContentTypeUID string `json:"contentType" gorm:"not null;uniqueIndex:idx_release_action_entry;index:idx_release_action_target"`
+	EntryID        uint   `json:"entryId" gorm:"not null;uniqueIndex:idx_release_action_entry;index:idx_release_action_target"`
+	Action         string `json:"action" gorm:"not null"` // publish, unpublish
+
+	// State of the entry before the release was executed, restored by a revert
+	PreviousStatus      string     `json:"previousStatus,omitempty"`
+	PreviousPublishedAt *time.Time `json:"previousPublishedAt,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// SubmissionConfig lets anonymous clients create draft entries of a content type, e.g.
+// contact requests or event sign-ups
+type SubmissionConfig struct {
+	Enabled   bool     `json:"enabled"`
+	Fields    []string `json:"fields"`              // Fields a submission may set
+	RateLimit int      `json:"rateLimit,omitempty"` // Submissions per IP address per hour, 10 by default
+	Honeypot  string   `json:"honeypot,omitempty"`  // Hidden form field; submissions filling it are discarded
+	Captcha   bool     `json:"captcha"`             // Require a CAPTCHA response
+}
+
+func (s *SubmissionConfig) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		*s = SubmissionConfig{}
+		return nil
+	}
+	if len(bytes) == 0 {
+		*s = SubmissionConfig{}
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s SubmissionConfig) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(s)
+	return string(bytes), err
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+func (l *StringList) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	bytes, err := json.Marshal(l)
+	return string(bytes), err
+}
+
+// Webhook is an endpoint notified about content and media events
+type Webhook struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Name    string `json:"name" gorm:"not null"`
+	URL     string `json:"url" gorm:"not null"`
+	Secret  string `json:"secret" gorm:"not null"` // HMAC-SHA256 key of the payload signature
+	Enabled bool   `json:"enabled" gorm:"default:true"`
+
+	// Subscribed events, e.g. entry.create, media.upload
+	Events StringList `json:"events" gorm:"type:text"`
+	// Content type UIDs entry events are limited to, empty for all
+	ContentTypes StringList `json:"contentTypes" gorm:"type:text"`
+
+	CreatedByID *uint `json:"createdById"`
+	CreatedBy   *User `json:"createdBy,omitempty" gorm:"foreignKey:CreatedByID"`
+}
+
+// WebhookDelivery is an event queued for a webhook and the result of its last attempt
+type WebhookDelivery struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	WebhookID      uint   `json:"webhookId" gorm:"not null;index"`
+	Event          string `json:"event" gorm:"not null"`
+	ContentTypeUID string `json:"contentTypeUid"`
+	Payload        string `json:"payload" gorm:"type:text"`
+
+	// pending (queued or waiting for a retry), sending, success, failed (attempts exhausted)
+	Status        string     `json:"status" gorm:"not null;default:pending;index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"index"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+
+	// Last attempt
+	ResponseCode int    `json:"responseCode"`
+	ResponseBody string `json:"responseBody" gorm:"type:text"` // Truncated
+	Error        string `json:"error"`
+	DurationMs   int64  `json:"durationMs"`
+
+	AttemptLog []WebhookDeliveryAttempt `json:"attemptLog,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookDeliveryAttempt is the result of one attempt to send a delivery
+type WebhookDeliveryAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	DeliveryID uint `json:"deliveryId" gorm:"not null;index"`
+	Attempt    int  `json:"attempt"` // Counted from 1, again after a manual retry
+
+	ResponseCode int    `json:"responseCode"`
+	ResponseBody string `json:"responseBody" gorm:"type:text"` // Truncated
+	Error        string `json:"error"`
+	DurationMs   int64  `json:"durationMs"`
+}
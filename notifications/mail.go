@@ -0,0 +1,96 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Mail is a plain text email
+type Mail struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// MailSender sends emails. FileSink is the sender for development; an SMTP or API
+// based sender implements the same interface.
+type MailSender interface {
+	SendMail(ctx context.Context, mail Mail) error
+}
+
+// EmailChannel delivers notifications as emails to the address of the recipient
+type EmailChannel struct {
+	Sender  MailSender
+	From    string
+	BaseURL string // Admin UI address that notification links are relative to
+}
+
+// NewEmailChannel returns a channel that sends notifications with sender
+func NewEmailChannel(sender MailSender, from, baseURL string) *EmailChannel {
+	return &EmailChannel{Sender: sender, From: from, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (e *EmailChannel) Name() string {
+	return "email"
+}
+
+func (e *EmailChannel) Send(ctx context.Context, m Message) error {
+	// Users without an address only see notifications in the application
+	if m.Recipient.Email == "" {
+		return nil
+	}
+
+	body := m.Body
+	if m.Link != "" {
+		if body != "" {
+			body += "\n\n"
+		}
+		body += e.BaseURL + m.Link
+	}
+
+	return e.Sender.SendMail(ctx, Mail{
+		From:    e.From,
+		To:      m.Recipient.Email,
+		Subject: m.Title,
+		Body:    body,
+	})
+}
+
+// FileSink writes every email as an .eml file to a directory instead of sending it
+type FileSink struct {
+	Dir string
+}
+
+var fileSinkSeq uint64
+
+// NewFileSink returns a sink writing to dir, which is created if needed
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSink{Dir: dir}, nil
+}
+
+func (s *FileSink) SendMail(ctx context.Context, mail Mail) error {
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", mail.From)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	name := fmt.Sprintf("%s-%06d.eml", now.Format("20060102-150405.000000"), atomic.AddUint64(&fileSinkSeq, 1))
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(b.String()), 0o644)
+}
@@ -0,0 +1,92 @@
+// Package notifications delivers in-app notifications through other channels, e.g. email.
+// The notifications themselves are stored by the handlers; every registered channel gets
+// a copy of each new notification.
+//
+// Code compiled into the server can add channels, e.g. from a plugin:
+//
+//	notifications.Register(notifications.NewEmailChannel(smtpSender, from, adminURL))
+package notifications
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// deliveryTimeout limits a single delivery through a channel
+const deliveryTimeout = 30 * time.Second
+
+// Recipient is the user a notification is for
+type Recipient struct {
+	UserID   uint
+	Email    string
+	Username string
+}
+
+// Message is a stored notification handed to the delivery channels
+type Message struct {
+	NotificationID uint
+	Type           string
+	Title          string
+	Body           string
+	Link           string // Path in the admin UI, e.g. /content-types/article/entries/12
+	Recipient      Recipient
+	CreatedAt      time.Time
+}
+
+// Channel delivers notifications outside the application
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, m Message) error
+}
+
+var channels = struct {
+	sync.RWMutex
+	list []Channel
+}{}
+
+// Register adds a delivery channel, usually at startup
+func Register(ch Channel) {
+	channels.Lock()
+	defer channels.Unlock()
+	channels.list = append(channels.list, ch)
+}
+
+// Channels returns the names of the registered delivery channels
+func Channels() []string {
+	channels.RLock()
+	defer channels.RUnlock()
+
+	names := make([]string, len(channels.list))
+	for i, ch := range channels.list {
+		names[i] = ch.Name()
+	}
+	return names
+}
+
+// Deliver sends messages through every channel in the background. Failed deliveries are
+// logged; the notification stays in the application either way.
+func Deliver(messages ...Message) {
+	channels.RLock()
+	list := append([]Channel(nil), channels.list...)
+	channels.RUnlock()
+
+	for _, ch := range list {
+		for _, m := range messages {
+			go func(ch Channel, m Message) {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("Notification channel %s panicked: %v", ch.Name(), r)
+					}
+				}()
+
+				ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+				defer cancel()
+				if err := ch.Send(ctx, m); err != nil {
+					log.Printf("Notification %d via %s failed: %v", m.NotificationID, ch.Name(), err)
+				}
+			}(ch, m)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+// Package plugins lets features be compiled into the server without patching the routes.
+//
+// A plugin registers itself from an init function and is enabled at startup by listing
+// its name in the PLUGINS environment variable:
+//
+//	func init() {
+//		plugins.Register(&geoPlugin{})
+//	}
+//
+//	func (p *geoPlugin) Info() plugins.Info {
+//		return plugins.Info{Name: "geo", Version: "1.0.0", Description: "Geo point fields"}
+//	}
+//
+//	func (p *geoPlugin) Register(r *plugins.Registrar) error {
+//		r.FieldType(plugins.FieldType{Name: "geopoint", Validate: validatePoint})
+//		r.Model(&Place{})
+//		r.GET("/places", listPlaces) // served at /api/plugins/geo/places
+//		r.Hook(lifecycle.BeforeCreateAction, "stores", geocodeStore)
+//		return nil
+//	}
+//
+// Register is called only for enabled plugins, so disabled plugins add nothing.
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xivercms/xivercms/lifecycle"
+)
+
+// Plugin is a feature compiled into the server
+type Plugin interface {
+	Info() Info
+	// Register adds the plugin's field types, models, routes and hooks
+	Register(r *Registrar) error
+}
+
+// Info describes a plugin
+type Info struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// FieldType is a field type content type schemas can use as {"type": "<name>"}
+type FieldType struct {
+	Name string
+	// Validate checks a value written to a field, field is the field's schema definition.
+	// An error rejects the write with 400 Bad Request and the error as the field's message.
+	Validate func(value interface{}, field map[string]interface{}) error
+	// Serialize converts a stored value for public API responses, nil returns it as stored
+	Serialize func(value interface{}, field map[string]interface{}) interface{}
+	// Operators are additional filter operators for fields of this type, e.g. "$near"
+	Operators map[string]FilterOperator
+}
+
+// FilterOperator builds the SQL condition of filters[field][$op]=values. An error is
+// reported to the client as 400 Bad Request.
+type FilterOperator func(field FilterField, values []string) (string, []interface{}, error)
+
+// FilterField is the field a filter operator is applied to
+type FilterField struct {
+	Name   string
+	Schema map[string]interface{} // Schema definition of the field
+	Text   string                 // SQL expression of the value as text
+	Number string                 // SQL expression of the value as a number
+}
+
+// Registrar collects what a plugin registers
+type Registrar struct {
+	name       string
+	fieldTypes []FieldType
+	models     []interface{}
+	routes     []route
+	hooks      []hook
+	errs       []string
+}
+
+type route struct {
+	method   string
+	path     string
+	handlers []gin.HandlerFunc
+}
+
+type hook struct {
+	action      lifecycle.Action
+	contentType string
+	fn          lifecycle.Hook
+}
+
+// FieldType registers a field type
+func (r *Registrar) FieldType(fieldType FieldType) {
+	if !fieldTypeNamePattern.MatchString(fieldType.Name) {
+		r.errs = append(r.errs, fmt.Sprintf("invalid field type name %q", fieldType.Name))
+		return
+	}
+	for op := range fieldType.Operators {
+		if !strings.HasPrefix(op, "$") || len(op) < 2 {
+			r.errs = append(r.errs, fmt.Sprintf("filter operator %q of %s must start with $", op, fieldType.Name))
+			return
+		}
+	}
+	r.fieldTypes = append(r.fieldTypes, fieldType)
+}
+
+// Model registers models migrated with the built-in ones at startup
+func (r *Registrar) Model(models ...interface{}) {
+	r.models = append(r.models, models...)
+}
+
+// Handle registers an admin route under /api/plugins/<name>. Routes require a JWT or an
+// API token like the other admin routes.
+func (r *Registrar) Handle(method, path string, handlers ...gin.HandlerFunc) {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	r.routes = append(r.routes, route{method: method, path: path, handlers: handlers})
+}
+
+func (r *Registrar) GET(path string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, path, handlers...)
+}
+
+func (r *Registrar) POST(path string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, path, handlers...)
+}
+
+func (r *Registrar) PUT(path string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, path, handlers...)
+}
+
+func (r *Registrar) PATCH(path string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPatch, path, handlers...)
+}
+
+func (r *Registrar) DELETE(path string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, path, handlers...)
+}
+
+// Hook registers a lifecycle hook on a content type UID or lifecycle.AllContentTypes
+func (r *Registrar) Hook(action lifecycle.Action, contentTypeUID string, fn lifecycle.Hook) {
+	r.hooks = append(r.hooks, hook{action: action, contentType: contentTypeUID, fn: fn})
+}
+
+// builtinFieldTypes cannot be replaced by plugins
+var builtinFieldTypes = map[string]bool{
+	"string": true, "text": true, "richtext": true, "email": true, "url": true, "password": true,
+	"uid": true, "number": true, "integer": true, "float": true, "decimal": true, "boolean": true,
+	"date": true, "time": true, "datetime": true, "enum": true, "enumeration": true, "json": true,
+	"array": true, "object": true, "relation": true, "media": true, "mediaMultiple": true,
+	"component": true,
+}
+
+var (
+	namePattern          = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	fieldTypeNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+)
+
+var registry = struct {
+	sync.RWMutex
+	plugins    map[string]Plugin
+	enabled    map[string]*Registrar
+	order      []string
+	fieldTypes map[string]*FieldType
+	operators  map[string]bool
+}{
+	plugins:    make(map[string]Plugin),
+	enabled:    make(map[string]*Registrar),
+	fieldTypes: make(map[string]*FieldType),
+	operators:  make(map[string]bool),
+}
+
+// Register makes a plugin available, usually from an init function. It panics if the
+// name is invalid or already taken.
+func Register(p Plugin) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	name := p.Info().Name
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("plugins: invalid plugin name %q", name))
+	}
+	if _, exists := registry.plugins[name]; exists {
+		panic(fmt.Sprintf("plugins: plugin %s registered twice", name))
+	}
+	registry.plugins[name] = p
+}
+
+// Load enables the named plugins in order. Call it once at startup before migrating the
+// database and setting up routes.
+func Load(names []string) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	// Everything is validated before anything is applied, so a failing plugin leaves no hooks behind
+	loaded := make(map[string]*Registrar)
+	var order []string
+	fieldTypes := make(map[string]*FieldType)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		p, ok := registry.plugins[name]
+		if !ok {
+			return fmt.Errorf("plugin %s is not compiled in", name)
+		}
+		if _, ok := loaded[name]; ok {
+			continue
+		}
+
+		r := &Registrar{name: name}
+		if err := p.Register(r); err != nil {
+			return fmt.Errorf("plugin %s: %w", name, err)
+		}
+		if len(r.errs) > 0 {
+			return fmt.Errorf("plugin %s: %s", name, strings.Join(r.errs, "; "))
+		}
+
+		for i := range r.fieldTypes {
+			fieldType := &r.fieldTypes[i]
+			if builtinFieldTypes[fieldType.Name] {
+				return fmt.Errorf("plugin %s: field type %s is built in", name, fieldType.Name)
+			}
+			if _, exists := fieldTypes[fieldType.Name]; exists {
+				return fmt.Errorf("plugin %s: field type %s is already registered", name, fieldType.Name)
+			}
+			fieldTypes[fieldType.Name] = fieldType
+		}
+
+		loaded[name] = r
+		order = append(order, name)
+	}
+
+	// Hooks of earlier plugins run first
+	for _, name := range order {
+		r := loaded[name]
+		registry.enabled[name] = r
+		registry.order = append(registry.order, name)
+		for _, h := range r.hooks {
+			lifecycle.On(h.action, h.contentType, h.fn)
+		}
+	}
+	for name, fieldType := range fieldTypes {
+		registry.fieldTypes[name] = fieldType
+		for op := range fieldType.Operators {
+			registry.operators[op] = true
+		}
+	}
+	return nil
+}
+
+// Models returns the models of the enabled plugins
+func Models() []interface{} {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var models []interface{}
+	for _, name := range registry.order {
+		models = append(models, registry.enabled[name].models...)
+	}
+	return models
+}
+
+// MountRoutes adds the routes of each enabled plugin to group under /<name>
+func MountRoutes(group *gin.RouterGroup) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for _, name := range registry.order {
+		pluginGroup := group.Group("/" + name)
+		for _, rt := range registry.enabled[name].routes {
+			pluginGroup.Handle(rt.method, rt.path, rt.handlers...)
+		}
+	}
+}
+
+// LookupFieldType returns a field type registered by an enabled plugin
+func LookupFieldType(name string) (*FieldType, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	fieldType, ok := registry.fieldTypes[name]
+	return fieldType, ok
+}
+
+// IsFilterOperator reports whether an enabled field type provides a filter operator
+func IsFilterOperator(op string) bool {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.operators[op]
+}
+
+// Summary describes a plugin for the introspection endpoint
+type Summary struct {
+	Info
+	Enabled    bool               `json:"enabled"`
+	FieldTypes []FieldTypeSummary `json:"fieldTypes"`
+	Models     []string           `json:"models"`
+	Routes     []RouteSummary     `json:"routes"`
+	Hooks      []HookSummary      `json:"hooks"`
+}
+
+type FieldTypeSummary struct {
+	Name      string   `json:"name"`
+	Operators []string `json:"operators"`
+}
+
+type RouteSummary struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+type HookSummary struct {
+	Action      lifecycle.Action `json:"action"`
+	ContentType string           `json:"contentType"`
+}
+
+// List describes every compiled-in plugin, sorted by name. Routes are relative to prefix.
+func List(prefix string) []Summary {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	summaries := make([]Summary, 0, len(registry.plugins))
+	for name, p := range registry.plugins {
+		summary := Summary{
+			Info:       p.Info(),
+			FieldTypes: []FieldTypeSummary{},
+			Models:     []string{},
+			Routes:     []RouteSummary{},
+			Hooks:      []HookSummary{},
+		}
+
+		if r, ok := registry.enabled[name]; ok {
+			summary.Enabled = true
+			for _, fieldType := range r.fieldTypes {
+				operators := make([]string, 0, len(fieldType.Operators))
+				for op := range fieldType.Operators {
+					operators = append(operators, op)
+				}
+				sort.Strings(operators)
+				summary.FieldTypes = append(summary.FieldTypes, FieldTypeSummary{Name: fieldType.Name, Operators: operators})
+			}
+			for _, model := range r.models {
+				summary.Models = append(summary.Models, reflect.Indirect(reflect.ValueOf(model)).Type().Name())
+			}
+			for _, rt := range r.routes {
+				summary.Routes = append(summary.Routes, RouteSummary{Method: rt.method, Path: prefix + "/" + name + rt.path})
+			}
+			for _, h := range r.hooks {
+				summary.Hooks = append(summary.Hooks, HookSummary{Action: h.action, ContentType: h.contentType})
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
@@ -0,0 +1,163 @@
+// Package realtime fans out entry change events to streaming connections in this process.
+//
+// Every event gets an increasing ID and is kept in a bounded history, so a client that
+// reconnects with the ID of the last event it received gets the events it missed.
+// IDs start at the startup time in microseconds, so IDs of a previous run are never
+// mistaken for IDs of this one.
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EntryCreate    = "entry.create"
+	EntryUpdate    = "entry.update"
+	EntryPublish   = "entry.publish"
+	EntryUnpublish = "entry.unpublish"
+	EntryDelete    = "entry.delete"
+)
+
+// Event is a change of a published entry
+type Event struct {
+	ID          uint64                 `json:"id"`
+	Type        string                 `json:"type"`
+	ContentType string                 `json:"contentType"`
+	EntryID     uint                   `json:"entryId"`
+	Entry       map[string]interface{} `json:"entry,omitempty"` // Public representation, absent for unpublish and delete
+	Restricted  bool                   `json:"-"`               // Only for subscribers in the entry's access list
+	CreatedAt   time.Time              `json:"createdAt"`
+}
+
+const (
+	// DefaultHistorySize is the number of events kept for resuming
+	DefaultHistorySize = 1000
+	// subscriberBuffer is the number of events a subscriber may fall behind before it is dropped
+	subscriberBuffer = 256
+)
+
+// Broker distributes published events to subscriptions
+type Broker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event // Ring buffer of the latest events
+	start       int     // Index of the oldest event in history
+	size        int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroker returns a broker keeping historySize events for resuming
+func NewBroker(historySize int) *Broker {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Broker{
+		nextID:      uint64(time.Now().UnixMicro()),
+		history:     make([]Event, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Default is the broker of the process
+var Default = NewBroker(DefaultHistorySize)
+
+// Subscription receives the events published after it was created
+type Subscription struct {
+	// C delivers events in order. It is closed when the subscription is closed, also when
+	// the subscriber fell too far behind; the client should then reconnect and resume.
+	C <-chan Event
+	// LastID is the ID of the last event published before the subscription started
+	LastID uint64
+
+	ch     chan Event
+	broker *Broker
+	closed bool
+}
+
+// Publish assigns IDs to events and delivers them to every subscription
+func (b *Broker) Publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		event.ID = b.nextID
+		b.nextID++
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = time.Now().UTC()
+		}
+
+		// Remember the event, overwriting the oldest once the history is full
+		if b.size < len(b.history) {
+			b.history[(b.start+b.size)%len(b.history)] = event
+			b.size++
+		} else {
+			b.history[b.start] = event
+			b.start = (b.start + 1) % len(b.history)
+		}
+
+		for sub := range b.subscribers {
+			select {
+			case sub.ch <- event:
+			default:
+				// Never block publishers on a slow client
+				b.closeLocked(sub)
+			}
+		}
+	}
+}
+
+// Subscribe starts a subscription. With resume set, the events published after
+// lastEventID are returned for replay; ok is false if some of them are no longer
+// available, and the client should reload its state instead.
+func (b *Broker) Subscribe(lastEventID uint64, resume bool) (sub *Subscription, replay []Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, LastID: b.nextID - 1, ch: ch, broker: b}
+	b.subscribers[sub] = struct{}{}
+
+	if !resume {
+		return sub, nil, true
+	}
+
+	// IDs are consecutive: everything after lastEventID is available if the oldest
+	// remembered event is at most the one right after it
+	oldest := b.nextID
+	if b.size > 0 {
+		oldest = b.history[b.start].ID
+	}
+	if lastEventID+1 < oldest || lastEventID >= b.nextID {
+		return sub, nil, false
+	}
+
+	for i := 0; i < b.size; i++ {
+		event := b.history[(b.start+i)%len(b.history)]
+		if event.ID > lastEventID {
+			replay = append(replay, event)
+		}
+	}
+	return sub, replay, true
+}
+
+// Close ends the subscription and closes C
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.closeLocked(s)
+}
+
+func (b *Broker) closeLocked(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/xivercms/xivercms/handlers"
 	"github.com/xivercms/xivercms/middleware"
+	"github.com/xivercms/xivercms/plugins"
 )
 
 func SetupRoutes(r *gin.Engine) {
@@ -15,6 +16,15 @@ func SetupRoutes(r *gin.Engine) {
 	// Serve uploaded media files
 	r.Static("/api/uploads", "./uploads")
 
+	// GraphQL API generated from the visible content types
+	// Queries follow the public API access rules, mutations require a JWT or an API token like the admin entry routes
+	r.GET("/graphql", middleware.OptionalAPITokenMiddleware(), middleware.OptionalAuthMiddleware(), handlers.GraphQL)
+	r.POST("/graphql", middleware.OptionalAPITokenMiddleware(), middleware.OptionalAuthMiddleware(), handlers.GraphQL)
+
+	// XML sitemap of published entries of the content types with inSitemap enabled
+	r.GET("/sitemap.xml", handlers.Sitemap)
+	r.GET("/sitemaps/:page", handlers.SitemapPage)
+
 	// Public routes
 	public := r.Group("/api")
 	{
@@ -25,6 +35,18 @@ func SetupRoutes(r *gin.Engine) {
 		// Public roles for registration (must be before dynamic content routes)
 		public.GET("/roles/public", handlers.GetPublicRoles)
 
+		// Redirect resolution for changed slugs and removed entries (must be before dynamic content routes)
+		public.GET("/redirects/resolve", middleware.OptionalAuthMiddleware(), handlers.ResolveRedirect)
+
+		// Change feed of published entries over Server-Sent Events and WebSocket (must be before dynamic content routes)
+		// Not cached; browsers may pass the JWT as access_token since they cannot set headers on these connections
+		public.GET("/stream", middleware.QueryTokenMiddleware(), middleware.OptionalAuthMiddleware(), handlers.StreamEntries)
+		public.GET("/stream/ws", middleware.QueryTokenMiddleware(), middleware.OptionalAuthMiddleware(), handlers.StreamEntriesWebSocket)
+
+		// Anonymous submissions to content types that enable them, e.g. contact forms
+		// URL: POST /api/{uid} (e.g., /api/contact-requests); protected POST routes take precedence
+		public.POST("/:uid", middleware.OptionalAuthMiddleware(), handlers.PublicSubmitContentEntry)
+
 		// Public content access - uses OptionalAuthMiddleware to check auth if provided
 		// Access is controlled by accessType in ContentType (public, authenticated, moderator, admin)
 		// Simplified URLs: /api/{content-type} and /api/{content-type}/{id}
@@ -32,9 +54,19 @@ func SetupRoutes(r *gin.Engine) {
 		// Only content entries are available via public API
 		publicContent := public.Group("")
 		publicContent.Use(middleware.OptionalAuthMiddleware())
+		publicContent.Use(middleware.ResponseCacheMiddleware()) // No-op unless CACHE_ENABLED
 		{
+			// Public API: Aggregates and facets over published entries (must be before /:uid/:id)
+			// URL: /api/aggregate/{uid} (e.g., /api/aggregate/products?groupBy=category)
+			publicContent.GET("/aggregate/:uid", handlers.PublicAggregateContentEntries)
+
+			// Public API: RSS, Atom and JSON Feed of published entries (must be before /:uid/:id)
+			// URL: /api/feeds/{uid}/{format} (e.g., /api/feeds/blog/rss, /api/feeds/news/json)
+			publicContent.GET("/feeds/:uid/:format", handlers.PublicGetFeed)
+
 			// Public API: Get single entry by ID (must be before /:uid)
 			// URL: /api/{uid}/{id} (e.g., /api/articles/1, /api/books/123)
+			// Drafts are returned with a preview token (X-Preview-Token or ?previewToken=)
 			publicContent.GET("/:uid/:id", handlers.PublicGetContentEntry)
 
 			// Public API: Get all entries for a content type (registered last to catch remaining routes)
@@ -91,6 +123,24 @@ func SetupRoutes(r *gin.Engine) {
 			apiTokens.DELETE("/:id", handlers.DeleteAPIToken)
 		}
 
+		// Preview tokens: shareable access to unpublished entries through the public API
+		previewTokens := protected.Group("/preview-tokens")
+		{
+			previewTokens.GET("", handlers.GetPreviewTokens)
+			previewTokens.GET("/:id", handlers.GetPreviewToken)
+			previewTokens.POST("", handlers.CreatePreviewToken)
+			previewTokens.DELETE("/:id", handlers.RevokePreviewToken)
+		}
+
+		// Notifications of the current user
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("", handlers.GetNotifications)
+			notifications.GET("/unread-count", handlers.GetUnreadNotificationCount)
+			notifications.POST("/read-all", handlers.MarkAllNotificationsRead)
+			notifications.POST("/:id/read", handlers.MarkNotificationRead)
+		}
+
 		// Media Library
 		media := protected.Group("/upload")
 		{
@@ -135,13 +185,81 @@ func SetupRoutes(r *gin.Engine) {
 			contentEntries.DELETE("/:id", handlers.DeleteContentEntry)
 			contentEntries.GET("/:id/history", handlers.GetContentHistory)
 
+			// Tree structure (tree-enabled content types)
+			contentEntries.POST("/:id/move", handlers.MoveContentEntry)
+			contentEntries.GET("/:id/path", handlers.GetContentEntryPath)
+
 			// Relations
 			contentEntries.GET("/:id/relations", handlers.GetRelations)
 			contentEntries.POST("/:id/relations", handlers.CreateRelation)
 			contentEntries.DELETE("/:id/relations/:relationId", handlers.DeleteRelation)
 			contentEntries.GET("/:id/relations/:field", handlers.GetRelatedEntries)
+			contentEntries.PUT("/:id/relations/:field/order", handlers.ReorderRelations)
+			contentEntries.POST("/:id/relations/:field/items", handlers.InsertRelation)
+			contentEntries.GET("/:id/referenced-by", handlers.GetReferencingEntries)
+
+			// Entry access lists: restrict public reads to roles and users
+			contentEntries.GET("/:id/access", handlers.GetEntryAccess)
+			contentEntries.PUT("/:id/access", handlers.UpdateEntryAccess)
+
+			// Editorial comments (permission subject "comment")
+			contentEntries.GET("/:id/comments", middleware.RequirePermission("read", "comment"), handlers.GetComments)
+			contentEntries.POST("/:id/comments", middleware.RequirePermission("create", "comment"), handlers.CreateComment)
+			contentEntries.PUT("/:id/comments/:commentId", middleware.RequirePermission("update", "comment"), handlers.UpdateComment)
+			contentEntries.DELETE("/:id/comments/:commentId", middleware.RequirePermission("delete", "comment"), handlers.DeleteComment)
+			contentEntries.POST("/:id/comments/:commentId/resolve", middleware.RequirePermission("resolve", "comment"), handlers.ResolveComment)
+			contentEntries.POST("/:id/comments/:commentId/unresolve", middleware.RequirePermission("resolve", "comment"), handlers.UnresolveComment)
+		}
+
+		// Nested tree of all entries (tree-enabled content types)
+		protected.GET("/admin/content-types/:uid/tree", handlers.GetContentTree)
+
+		// Redirects
+		redirects := protected.Group("/redirects")
+		{
+			redirects.GET("", handlers.GetRedirects)
+			redirects.GET("/:id", handlers.GetRedirect)
+			redirects.POST("", handlers.CreateRedirect)
+			redirects.PUT("/:id", handlers.UpdateRedirect)
+			redirects.DELETE("/:id", handlers.DeleteRedirect)
+		}
+
+		// Webhooks
+		hooks := protected.Group("/webhooks")
+		{
+			hooks.GET("", handlers.GetWebhooks)
+			hooks.GET("/:id", handlers.GetWebhook)
+			hooks.POST("", handlers.CreateWebhook)
+			hooks.PUT("/:id", handlers.UpdateWebhook)
+			hooks.DELETE("/:id", handlers.DeleteWebhook)
+			hooks.POST("/:id/test", handlers.TestWebhook)
+			hooks.GET("/:id/deliveries", handlers.GetWebhookDeliveries)
+			hooks.POST("/:id/deliveries/:deliveryId/retry", handlers.RetryWebhookDelivery)
 		}
 
+		// Releases: entries published or unpublished together, now or at a scheduled time
+		releases := protected.Group("/releases")
+		{
+			releases.GET("", handlers.GetReleases)
+			releases.GET("/:id", handlers.GetRelease)
+			releases.POST("", handlers.CreateRelease)
+			releases.PUT("/:id", handlers.UpdateRelease)
+			releases.DELETE("/:id", handlers.DeleteRelease)
+			releases.POST("/:id/actions", handlers.AddReleaseActions)
+			releases.DELETE("/:id/actions/:actionId", handlers.DeleteReleaseAction)
+			releases.GET("/:id/validate", handlers.ValidateRelease)
+			releases.POST("/:id/execute", handlers.ExecuteRelease)
+			releases.POST("/:id/revert", handlers.RevertRelease)
+		}
+
+		// Plugins: introspection and the admin routes of enabled plugins under /api/plugins/{name}
+		protected.GET("/admin/plugins", handlers.GetPlugins)
+		plugins.MountRoutes(protected.Group("/plugins"))
+
+		// Response cache monitoring
+		protected.GET("/admin/cache/stats", handlers.GetCacheStats)
+		protected.DELETE("/admin/cache", handlers.PurgeCache)
+
 		// Audit Logs
 		auditLogs := protected.Group("/audit-logs")
 		{
@@ -0,0 +1,75 @@
+package submissions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Providers are the CAPTCHA services with a siteverify API
+var Providers = map[string]string{
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// SiteVerifier verifies responses with a siteverify API shared by reCAPTCHA, hCaptcha
+// and Cloudflare Turnstile: a form POST of secret, response and remoteip answered with
+// {"success": true|false}
+type SiteVerifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// NewSiteVerifier returns a verifier for a provider name, or for a siteverify URL
+func NewSiteVerifier(provider, secret string) (*SiteVerifier, error) {
+	endpoint, ok := Providers[provider]
+	if !ok {
+		if !strings.HasPrefix(provider, "https://") && !strings.HasPrefix(provider, "http://") {
+			return nil, fmt.Errorf("unknown CAPTCHA provider %q", provider)
+		}
+		endpoint = provider
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("CAPTCHA provider %s requires a secret", provider)
+	}
+	return &SiteVerifier{URL: endpoint, Secret: secret, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (v *SiteVerifier) Verify(ctx context.Context, response, remoteIP string) (bool, error) {
+	if response == "" {
+		return false, nil
+	}
+
+	form := url.Values{"secret": {v.Secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("CAPTCHA verification returned %s", resp.Status)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,131 @@
+// Package submissions protects and reports anonymous entry submissions: per-client rate
+// limits, CAPTCHA verification and notifications about new submissions.
+//
+// Code compiled into the server can replace the CAPTCHA verifier and subscribe to new
+// submissions, e.g. from a plugin:
+//
+//	submissions.SetVerifier(myVerifier)
+//	submissions.OnSubmission(func(s submissions.Submission) {
+//		sendToSlack(s.ContentType, s.EntryID)
+//	})
+package submissions
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Submission is an entry created by an anonymous client
+type Submission struct {
+	ContentType string
+	EntryID     uint
+	Data        map[string]interface{}
+	IP          string
+	CreatedAt   time.Time
+}
+
+// Notifier is called after a submission is stored
+type Notifier func(s Submission)
+
+var notifiers = struct {
+	sync.RWMutex
+	list []Notifier
+}{}
+
+// OnSubmission registers a notifier, usually at startup
+func OnSubmission(fn Notifier) {
+	notifiers.Lock()
+	defer notifiers.Unlock()
+	notifiers.list = append(notifiers.list, fn)
+}
+
+// Notify runs the notifiers in the background, so slow notifiers never delay the client
+func Notify(s Submission) {
+	notifiers.RLock()
+	list := append([]Notifier(nil), notifiers.list...)
+	notifiers.RUnlock()
+
+	for _, fn := range list {
+		go func(fn Notifier) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Submission notifier panicked: %v", r)
+				}
+			}()
+			fn(s)
+		}(fn)
+	}
+}
+
+// Verifier checks the CAPTCHA response a client sent with a submission
+type Verifier interface {
+	Verify(ctx context.Context, response, remoteIP string) (bool, error)
+}
+
+var verifier = struct {
+	sync.RWMutex
+	v Verifier
+}{}
+
+// SetVerifier sets the CAPTCHA verifier, nil disables CAPTCHA support
+func SetVerifier(v Verifier) {
+	verifier.Lock()
+	defer verifier.Unlock()
+	verifier.v = v
+}
+
+// CurrentVerifier returns the CAPTCHA verifier, nil if none is configured
+func CurrentVerifier() Verifier {
+	verifier.RLock()
+	defer verifier.RUnlock()
+	return verifier.v
+}
+
+// Limiter counts requests per key in fixed windows
+type Limiter struct {
+	mu        sync.Mutex
+	window    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	count int
+	reset time.Time
+}
+
+// NewLimiter returns a limiter with windows of the given length
+func NewLimiter(window time.Duration) *Limiter {
+	return &Limiter{window: window, buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Allow counts a request for key and reports whether it is within limit. When it is not,
+// retryAfter is the time until the window resets.
+func (l *Limiter) Allow(key string, limit int) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > l.window {
+		// Forget expired windows so that one-off clients do not accumulate
+		for k, b := range l.buckets {
+			if now.After(b.reset) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, exists := l.buckets[key]
+	if !exists || now.After(b.reset) {
+		b = &bucket{reset: now.Add(l.window)}
+		l.buckets[key] = b
+	}
+	if b.count >= limit {
+		return false, b.reset.Sub(now)
+	}
+	b.count++
+	return true, 0
+}
@@ -0,0 +1,366 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xivercms/xivercms/database"
+	"github.com/xivercms/xivercms/models"
+	"gorm.io/gorm"
+)
+
+// Events webhooks can subscribe to
+const (
+	EntryCreate    = "entry.create"
+	EntryUpdate    = "entry.update"
+	EntryDelete    = "entry.delete"
+	EntryPublish   = "entry.publish"
+	EntryUnpublish = "entry.unpublish"
+	EntrySubmit    = "entry.submit" // Anonymous submission, see submissions
+	MediaUpload    = "media.upload"
+	MediaDelete    = "media.delete"
+
+	// Ping sent by the test endpoint, not subscribable
+	WebhookTest = "webhook.test"
+)
+
+var Events = []string{EntryCreate, EntryUpdate, EntryDelete, EntryPublish, EntryUnpublish, EntrySubmit, MediaUpload, MediaDelete}
+
+// IsEvent reports whether webhooks can subscribe to an event
+func IsEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Options configure delivery
+type Options struct {
+	MaxAttempts  int           // Attempts before a delivery is marked failed
+	RetryBase    time.Duration // Delay before the first retry, doubled for each further retry
+	Timeout      time.Duration // Timeout of a request to the endpoint
+	PollInterval time.Duration // How often the queue is checked for due deliveries
+}
+
+const (
+	maxRetryDelay    = time.Hour
+	maxResponseBody  = 1024
+	deliveryBatch    = 10
+	signatureVersion = "sha256"
+)
+
+var (
+	options = Options{MaxAttempts: 8, RetryBase: 30 * time.Second, Timeout: 10 * time.Second, PollInterval: 5 * time.Second}
+	client  = &http.Client{Timeout: options.Timeout}
+	wake    = make(chan struct{}, 1)
+	once    sync.Once
+)
+
+// Start runs the delivery worker. Deliveries interrupted by a restart or a crashed
+// instance are queued again once their claim is stale.
+func Start(opts Options) {
+	once.Do(func() {
+		if opts.MaxAttempts > 0 {
+			options.MaxAttempts = opts.MaxAttempts
+		}
+		if opts.RetryBase > 0 {
+			options.RetryBase = opts.RetryBase
+		}
+		if opts.Timeout > 0 {
+			options.Timeout = opts.Timeout
+		}
+		if opts.PollInterval > 0 {
+			options.PollInterval = opts.PollInterval
+		}
+		client = &http.Client{Timeout: options.Timeout}
+
+		go run()
+	})
+}
+
+// Notify wakes the worker up, call it after committing a change that queued deliveries
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue queues an event for every enabled webhook subscribed to it. Pass the transaction
+// of the change so that deliveries are queued only if the change is committed. data is
+// merged into the payload next to event and createdAt.
+func Enqueue(db *gorm.DB, event, contentTypeUID string, data map[string]interface{}) error {
+	var hooks []models.Webhook
+	if err := db.Where("enabled = ?", true).Find(&hooks).Error; err != nil {
+		return err
+	}
+
+	for i := range hooks {
+		if !subscribed(&hooks[i], event, contentTypeUID) {
+			continue
+		}
+		if _, err := EnqueueFor(db, &hooks[i], event, contentTypeUID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueFor queues an event for one webhook regardless of its subscriptions
+func EnqueueFor(db *gorm.DB, hook *models.Webhook, event, contentTypeUID string, data map[string]interface{}) (*models.WebhookDelivery, error) {
+	payload := map[string]interface{}{
+		"event":     event,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	for k, v := range data {
+		payload[k] = v
+	}
+	if contentTypeUID != "" {
+		payload["contentType"] = contentTypeUID
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID:      hook.ID,
+		Event:          event,
+		ContentTypeUID: contentTypeUID,
+		Payload:        string(raw),
+		Status:         "pending",
+		NextAttemptAt:  time.Now(),
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Sign returns the signature header value of a payload: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscribed reports whether a webhook receives an event. Content type filters apply to entry events.
+func subscribed(hook *models.Webhook, event, contentTypeUID string) bool {
+	found := false
+	for _, e := range hook.Events {
+		if e == event {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	if contentTypeUID == "" || len(hook.ContentTypes) == 0 {
+		return true
+	}
+	for _, uid := range hook.ContentTypes {
+		if uid == contentTypeUID {
+			return true
+		}
+	}
+	return false
+}
+
+func run() {
+	ticker := time.NewTicker(options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		processDue()
+
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// staleClaimAge is how long a claimed delivery may stay in sending: an instance sends the
+// claimed batch of a webhook one after another, each request taking up to the timeout
+func staleClaimAge() time.Duration {
+	return deliveryBatch*options.Timeout + time.Minute
+}
+
+// releaseStaleClaims queues deliveries again whose sender stopped while sending them.
+// Claims of other running instances are younger and left alone.
+func releaseStaleClaims() {
+	if err := database.DB.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND updated_at < ?", "sending", time.Now().Add(-staleClaimAge())).
+		Update("status", "pending").Error; err != nil {
+		log.Printf("Webhooks: failed to release stale deliveries: %v", err)
+	}
+}
+
+// processDue sends every delivery whose attempt is due, a batch at a time
+func processDue() {
+	releaseStaleClaims()
+
+	for {
+		var due []models.WebhookDelivery
+		if err := database.DB.Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+			Order("next_attempt_at ASC, id ASC").Limit(deliveryBatch).
+			Find(&due).Error; err != nil {
+			log.Printf("Webhooks: failed to load deliveries: %v", err)
+			return
+		}
+
+		// Endpoints are called in parallel, the deliveries of one endpoint in queue order
+		byWebhook := make(map[uint][]*models.WebhookDelivery)
+		for i := range due {
+			// Claim the delivery, another instance may have taken it
+			result := database.DB.Model(&models.WebhookDelivery{}).
+				Where("id = ? AND status = ?", due[i].ID, "pending").
+				Update("status", "sending")
+			if result.Error != nil || result.RowsAffected == 0 {
+				continue
+			}
+			byWebhook[due[i].WebhookID] = append(byWebhook[due[i].WebhookID], &due[i])
+		}
+
+		var wg sync.WaitGroup
+		for _, deliveries := range byWebhook {
+			wg.Add(1)
+			go func(deliveries []*models.WebhookDelivery) {
+				defer wg.Done()
+				for _, delivery := range deliveries {
+					attempt(delivery)
+				}
+			}(deliveries)
+		}
+		wg.Wait()
+
+		if len(due) < deliveryBatch {
+			return
+		}
+	}
+}
+
+// attempt sends a delivery once and records the result, scheduling a retry on failure
+func attempt(delivery *models.WebhookDelivery) {
+	var hook models.Webhook
+	if err := database.DB.First(&hook, delivery.WebhookID).Error; err != nil {
+		finish(delivery, "failed", models.WebhookDeliveryAttempt{Error: "Webhook deleted"})
+		return
+	}
+	if !hook.Enabled {
+		finish(delivery, "failed", models.WebhookDeliveryAttempt{Error: "Webhook disabled"})
+		return
+	}
+
+	delivery.Attempts++
+	started := time.Now()
+	code, body, err := send(&hook, delivery)
+	result := models.WebhookDeliveryAttempt{
+		DeliveryID:   delivery.ID,
+		Attempt:      delivery.Attempts,
+		ResponseCode: code,
+		ResponseBody: body,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+
+	if err == nil && code >= 200 && code < 300 {
+		finish(delivery, "success", result)
+		return
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Error = fmt.Sprintf("Endpoint responded with status %d", code)
+	}
+
+	if delivery.Attempts >= options.MaxAttempts {
+		finish(delivery, "failed", result)
+		return
+	}
+
+	delivery.NextAttemptAt = time.Now().Add(retryDelay(delivery.Attempts))
+	finish(delivery, "pending", result)
+}
+
+func send(hook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "XiverCMS-Webhooks/1.0")
+	req.Header.Set("X-XiverCMS-Event", delivery.Event)
+	req.Header.Set("X-XiverCMS-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-XiverCMS-Timestamp", timestamp)
+	req.Header.Set("X-XiverCMS-Signature", Sign(hook.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// finish stores the state of a delivery after an attempt. result.Attempt is 0 when nothing
+// was sent, otherwise the result is added to the delivery's attempt log.
+func finish(delivery *models.WebhookDelivery, status string, result models.WebhookDeliveryAttempt) {
+	updates := map[string]interface{}{
+		"status":          status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"response_code":   result.ResponseCode,
+		"response_body":   result.ResponseBody,
+		"error":           result.Error,
+		"duration_ms":     result.DurationMs,
+	}
+	if status == "success" {
+		updates["delivered_at"] = time.Now()
+	}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if result.Attempt > 0 {
+			if err := tx.Create(&result).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+	}); err != nil {
+		log.Printf("Webhooks: failed to record delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// retryDelay is the exponential backoff after a failed attempt: RetryBase, 2x, 4x, ... up to an hour
+func retryDelay(attempts int) time.Duration {
+	delay := options.RetryBase
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}